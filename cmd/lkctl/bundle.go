@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// bundleSpec 多产品许可证描述文件（bundle.yaml）
+//
+//	customer: ACME Corp
+//	duration: 365
+//	max_users: 10
+//	products:
+//	  - name: Editor
+//	    min_version: 1.0.0
+//	    max_version: 1.99.99
+//	    features: [export, print]
+//	  - name: Viewer
+//	    expires_at: 2026-12-31
+type bundleSpec struct {
+	Customer string              `yaml:"customer"`
+	Notes    string              `yaml:"notes"`
+	Duration int                 `yaml:"duration"` // 许可证整体有效期（天）
+	MaxUsers int                 `yaml:"max_users"`
+	Products []bundleProductSpec `yaml:"products"`
+}
+
+// bundleProductSpec 单个产品授权描述
+type bundleProductSpec struct {
+	Name       string   `yaml:"name"`
+	MinVersion string   `yaml:"min_version"`
+	MaxVersion string   `yaml:"max_version"`
+	Features   []string `yaml:"features"`
+	Duration   int      `yaml:"duration"`   // 有效期（天）
+	ExpiresAt  string   `yaml:"expires_at"` // 过期日期（2006-01-02），优先于 duration
+}
+
+// loadBundle 读取多产品许可证描述文件
+func loadBundle(path string) (*bundleSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle file: %v", err)
+	}
+
+	var spec bundleSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle file: %v", err)
+	}
+
+	if len(spec.Products) == 0 {
+		return nil, fmt.Errorf("bundle file must contain at least one product")
+	}
+
+	return &spec, nil
+}
+
+// apply 将描述文件应用到生成选项，命令行中未设置的值使用描述文件中的值
+func (b *bundleSpec) apply(options *license.GenerateOptions, now time.Time) error {
+	if options.CustomerName == "" {
+		options.CustomerName = b.Customer
+	}
+	if options.Notes == "" {
+		options.Notes = b.Notes
+	}
+	if options.MaxUsers == 0 {
+		options.MaxUsers = b.MaxUsers
+	}
+
+	var latest time.Time
+	for _, p := range b.Products {
+		grant := license.ProductGrant{
+			Name:       p.Name,
+			MinVersion: p.MinVersion,
+			MaxVersion: p.MaxVersion,
+			Features:   p.Features,
+		}
+
+		switch {
+		case p.ExpiresAt != "":
+			expiresAt, err := time.ParseInLocation("2006-01-02", p.ExpiresAt, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid expires_at for product %q: %v", p.Name, err)
+			}
+			grant.ExpiresAt = expiresAt
+		case p.Duration > 0:
+			grant.ExpiresAt = now.Add(time.Duration(p.Duration) * 24 * time.Hour)
+		}
+
+		if grant.ExpiresAt.After(latest) {
+			latest = grant.ExpiresAt
+		}
+		options.Products = append(options.Products, grant)
+	}
+
+	// 许可证整体有效期至少覆盖所有产品授权
+	if b.Duration > 0 {
+		options.Duration = time.Duration(b.Duration) * 24 * time.Hour
+	}
+	if !latest.IsZero() && now.Add(options.Duration).Before(latest) {
+		options.Duration = latest.Sub(now) + time.Minute
+	}
+
+	return nil
+}
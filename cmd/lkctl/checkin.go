@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/cuilan/license-key-verify/pkg/checkin"
+)
+
+func handleCheckInServer() {
+	fs := flag.NewFlagSet("checkin-server", flag.ExitOnError)
+	addr := fs.String("addr", ":8081", "Listen address")
+	privKey := fs.String("private-key", "keys/private.pem", "Path to private key file")
+	aesKey := fs.String("aes-key", "keys/aes.key", "Path to AES key file")
+	crl := fs.String("crl", "", "Signed revocation list, re-read on every check-in")
+	fs.Parse(os.Args[2:])
+
+	generator, err := loadGenerator(*privKey, *aesKey)
+	if err != nil {
+		fmt.Printf("Failed to create generator with keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	var opts []checkin.ServerOption
+	if *crl != "" {
+		opts = append(opts, checkin.WithRevocationListFile(*crl))
+	}
+
+	server, err := checkin.NewServer(generator, opts...)
+	if err != nil {
+		fmt.Printf("Failed to create check-in server: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Check-in server listening on %s (POST /v1/checkin)\n", *addr)
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		fmt.Printf("Server error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	mathrand "math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+)
+
+// embedTemplate 生成的Go源文件
+// AES密钥拆分为带掩码的份额（crypto.SplitKey），份额和掩码以随机顺序分散在文件的不同位置，运行时组合还原
+var embedTemplate = template.Must(template.New("embed").Parse(`// Code generated by lkctl embed; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+{{range .Head}}
+var {{.Name}} = []byte{{.Value}}
+{{end}}
+// KeyID 嵌入的公钥标识，与 lkctl embed 输出的标识相同
+const KeyID = "{{.KeyID}}"
+
+// publicKeyPEM 嵌入的公钥
+const publicKeyPEM = ` + "`{{.PublicKeyPEM}}`" + `
+
+// aesKey 组合嵌入的份额还原AES密钥
+func aesKey() ([]byte, error) {
+	return crypto.CombineKey(
+{{- range .Shares}}
+		crypto.KeyShare{Data: {{.Data}}, Mask: {{.Mask}}},
+{{- end}}
+	)
+}
+
+// NewVerifier 创建只信任嵌入密钥的验证器
+func NewVerifier(opts ...license.Option) (*license.Verifier, error) {
+	key, err := aesKey()
+	if err != nil {
+		return nil, err
+	}
+	return license.NewVerifier([]byte(publicKeyPEM), key, opts...)
+}
+{{range .Tail}}
+var {{.Name}} = []byte{{.Value}}
+{{end}}`))
+
+// embedPart 生成文件中的一个字节切片变量
+type embedPart struct {
+	Name  string
+	Value string
+}
+
+// embedShare 一份密钥在生成文件中的变量名
+type embedShare struct {
+	Data string
+	Mask string
+}
+
+func handleEmbed() {
+	fs := flag.NewFlagSet("embed", flag.ExitOnError)
+	keysDir := fs.String("keys-dir", "keys", "Directory for key files")
+	pubKey := fs.String("public-key", "", "Path to public key file (overrides --keys-dir)")
+	aesKeyPath := fs.String("aes-key", "", "Path to AES key file (overrides --keys-dir)")
+	pkg := fs.String("package", "main", "Package name of the generated file")
+	out := fs.String("out", "keys_gen.go", "Output file, - for standard output")
+	split := fs.Int("split", 3, "Number of masked shares the AES key is split into")
+	fs.Parse(os.Args[2:])
+
+	if !token.IsIdentifier(*pkg) {
+		fmt.Printf("Invalid package name: %q\n", *pkg)
+		os.Exit(1)
+	}
+	if *pubKey == "" {
+		*pubKey = filepath.Join(*keysDir, "public.pem")
+	}
+	if *aesKeyPath == "" {
+		*aesKeyPath = filepath.Join(*keysDir, "aes.key")
+	}
+
+	publicKeyPEM, err := os.ReadFile(*pubKey)
+	if err != nil {
+		fmt.Printf("Failed to read public key: %v\n", err)
+		os.Exit(1)
+	}
+	publicKey, err := crypto.LoadPublicKeyFromPEM(publicKeyPEM)
+	if err != nil {
+		fmt.Printf("Failed to load public key: %v\n", err)
+		os.Exit(1)
+	}
+	keyID, err := crypto.KeyID(publicKey)
+	if err != nil {
+		fmt.Printf("Failed to compute key ID: %v\n", err)
+		os.Exit(1)
+	}
+
+	shares := splitAESKey(*aesKeyPath, *split)
+
+	source, err := renderEmbed(*pkg, keyID, publicKeyPEM, shares)
+	if err != nil {
+		fmt.Printf("Failed to generate source: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "-" {
+		os.Stdout.Write(source)
+		return
+	}
+	if err := os.WriteFile(*out, source, 0644); err != nil {
+		fmt.Printf("Failed to write %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Embedded keys written to %s (key ID %s)\n", *out, keyID)
+}
+
+func handleKeyShares() {
+	fs := flag.NewFlagSet("keyshares", flag.ExitOnError)
+	aesKeyPath := fs.String("aes-key", "keys/aes.key", "Path to AES key file")
+	count := fs.Int("shares", 3, "Number of masked shares")
+	jsonOutput := fs.Bool("json", false, "Output the shares as JSON")
+	fs.Parse(os.Args[2:])
+
+	shares := splitAESKey(*aesKeyPath, *count)
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(shares, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println("[]crypto.KeyShare{")
+	for _, share := range shares {
+		fmt.Printf("\t{Data: []byte%s, Mask: []byte%s},\n", byteSliceLiteral(share.Data), byteSliceLiteral(share.Mask))
+	}
+	fmt.Println("}")
+}
+
+// splitAESKey 读取AES密钥文件并拆分为 n 份带掩码的份额，失败时退出
+func splitAESKey(aesKeyPath string, n int) []crypto.KeyShare {
+	aesKeyEncoded, err := os.ReadFile(aesKeyPath)
+	if err != nil {
+		fmt.Printf("Failed to read AES key file: %v\n", err)
+		os.Exit(1)
+	}
+	aesKey, err := crypto.DecodeBase64(strings.TrimSpace(string(aesKeyEncoded)))
+	if err != nil {
+		fmt.Printf("Failed to decode AES key: %v\n", err)
+		os.Exit(1)
+	}
+
+	shares, err := crypto.SplitKey(aesKey, n)
+	if err != nil {
+		fmt.Printf("Failed to split AES key: %v\n", err)
+		os.Exit(1)
+	}
+	return shares
+}
+
+// renderEmbed 生成并格式化嵌入密钥的源文件
+func renderEmbed(pkg, keyID string, publicKeyPEM []byte, shares []crypto.KeyShare) ([]byte, error) {
+	// 份额和掩码使用随机编号的变量名，一半放在文件开头，一半放在文件末尾
+	order := mathrand.Perm(2 * len(shares))
+	parts := make([]embedPart, len(order))
+	names := make([]embedShare, len(shares))
+	for i, share := range shares {
+		names[i].Data = fmt.Sprintf("keyPart%d", order[2*i])
+		names[i].Mask = fmt.Sprintf("keyPart%d", order[2*i+1])
+		parts[order[2*i]] = embedPart{names[i].Data, byteSliceLiteral(share.Data)}
+		parts[order[2*i+1]] = embedPart{names[i].Mask, byteSliceLiteral(share.Mask)}
+	}
+
+	var buf bytes.Buffer
+	err := embedTemplate.Execute(&buf, struct {
+		Package      string
+		KeyID        string
+		PublicKeyPEM string
+		Shares       []embedShare
+		Head, Tail   []embedPart
+	}{pkg, keyID, string(publicKeyPEM), names, parts[:len(parts)/2], parts[len(parts)/2:]})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// byteSliceLiteral 将字节转换为Go的 []byte 字面量，避免在二进制文件中出现连续的字符串
+func byteSliceLiteral(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("0x%02x", b)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
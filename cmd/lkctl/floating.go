@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/floating"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func handleFloating() {
+	fs := flag.NewFlagSet("floating", flag.ExitOnError)
+	addr := fs.String("addr", ":8090", "Listen address")
+	pubKey := fs.String("public-key", "keys/public.pem", "Path to public key file")
+	aesKey := fs.String("aes-key", "keys/aes.key", "Path to AES key file")
+	leaseKey := fs.String("lease-key", "", "Path to the private key used to sign seat leases (see 'lkctl gen --lease-key')")
+	product := fs.String("product", "", "Only serve licenses covering this product")
+	ttl := fs.Duration("ttl", floating.DefaultLeaseTTL, "Seat lease duration")
+	crl := fs.String("crl", "", "Signed revocation list")
+	fs.Parse(os.Args[2:])
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fmt.Println("Usage: lkctl floating [options] <license-file>")
+		os.Exit(1)
+	}
+
+	var opts []license.Option
+	if *product != "" {
+		opts = append(opts, license.WithProduct(*product, ""))
+	}
+	if *crl != "" {
+		data, err := os.ReadFile(*crl)
+		if err != nil {
+			fmt.Printf("Failed to read revocation list: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, license.WithRevocationList(data))
+	}
+
+	verifier, err := license.NewVerifierFromFiles(*pubKey, *aesKey, withLogger(opts...)...)
+	if err != nil {
+		fmt.Printf("Failed to create verifier: %v\n", err)
+		os.Exit(1)
+	}
+
+	licenseData, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Printf("Failed to read license file: %v\n", err)
+		os.Exit(1)
+	}
+
+	serverOpts := []floating.ServerOption{floating.WithLeaseTTL(*ttl)}
+	if *leaseKey != "" {
+		keyPEM, err := os.ReadFile(*leaseKey)
+		if err != nil {
+			fmt.Printf("Failed to read lease key: %v\n", err)
+			os.Exit(1)
+		}
+		privateKey, err := crypto.LoadPrivateKeyFromPEM(keyPEM)
+		if err != nil {
+			fmt.Printf("Failed to load lease key: %v\n", err)
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, floating.WithLeaseKey(privateKey))
+	}
+
+	server, err := floating.NewServer(verifier, licenseData, serverOpts...)
+	if err != nil {
+		fmt.Printf("Failed to start floating license server: %v\n", err)
+		os.Exit(1)
+	}
+
+	status, err := server.Status()
+	if err != nil {
+		fmt.Printf("Failed to get floating license server status: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Floating license server listening on %s\n", *addr)
+	fmt.Printf("License ID: %s, seats: %d, lease duration: %s\n", status.LicenseID, status.MaxUsers, ttl.Round(time.Second))
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		fmt.Printf("Server error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func handleIntegrity() {
+	fs := flag.NewFlagSet("integrity", flag.ExitOnError)
+	privKey := fs.String("private-key", "keys/private.pem", "Path to private key file")
+	output := fs.String("output", "integrity.json", "Output manifest file")
+	product := fs.String("product", "", "Product the license must cover to use this manifest")
+	version := fs.String("version", "", "Product version recorded in the manifest")
+	fs.Parse(os.Args[2:])
+
+	executables := fs.Args()
+	if len(executables) == 0 {
+		fmt.Println("Usage: lkctl integrity [options] <executable>...")
+		os.Exit(1)
+	}
+
+	// 完整性清单只需要签名，不需要AES密钥
+	generator, err := loadGenerator(*privKey, "")
+	if err != nil {
+		fmt.Printf("Failed to create generator with keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, manifest, err := generator.IssueIntegrityManifest(*product, *version, executables...)
+	if err != nil {
+		fmt.Printf("Failed to issue integrity manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Printf("Failed to save integrity manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Integrity manifest generated: %s\n", *output)
+	for _, binary := range manifest.Binaries {
+		fmt.Printf("  %s  %s (%d bytes)\n", binary.SHA256, binary.Name, binary.Size)
+	}
+}
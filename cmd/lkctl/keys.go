@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// loadGenerator 从密钥文件创建生成器，aesKeyPath 为空时不加载AES密钥（只用于签名）
+func loadGenerator(privateKeyPath, aesKeyPath string) (*license.Generator, error) {
+	privateKeyPEM, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %v", err)
+	}
+
+	var aesKey []byte
+	if aesKeyPath != "" {
+		aesKeyEncoded, err := os.ReadFile(aesKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read AES key file: %v", err)
+		}
+		aesKey, err = crypto.DecodeBase64(string(aesKeyEncoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode AES key: %v", err)
+		}
+	}
+
+	generator, err := license.NewGeneratorWithKeys(privateKeyPEM, aesKey)
+	if err != nil {
+		return nil, err
+	}
+	generator.SetLogger(logger)
+	return generator, nil
+}
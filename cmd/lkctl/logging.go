@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// logger 由全局选项 --log-format 设置，为 nil 时不输出日志
+var logger *slog.Logger
+
+// parseLogFormat 从命令行参数中移除 --log-format 并创建日志记录器，日志输出到标准错误
+// 该选项可以出现在子命令之前或之后
+func parseLogFormat() {
+	args := os.Args[:1]
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		var format string
+		switch {
+		case arg == "--log-format":
+			if i+1 >= len(os.Args) {
+				fmt.Println("--log-format requires text or json")
+				os.Exit(1)
+			}
+			i++
+			format = os.Args[i]
+		case strings.HasPrefix(arg, "--log-format="):
+			format = strings.TrimPrefix(arg, "--log-format=")
+		default:
+			args = append(args, arg)
+			continue
+		}
+
+		switch format {
+		case "text":
+			logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+		case "json":
+			logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+		default:
+			fmt.Printf("Invalid --log-format: %s (want text or json)\n", format)
+			os.Exit(1)
+		}
+	}
+	os.Args = args
+}
+
+// withLogger 在验证器选项中加入日志记录器
+func withLogger(opts ...license.Option) []license.Option {
+	if logger != nil {
+		opts = append(opts, license.WithLogger(logger))
+	}
+	return opts
+}
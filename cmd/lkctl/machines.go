@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// loadMachines 读取机器列表文件
+// 文件内容为 `lkctl get all --json` 输出的对象组成的JSON数组，也可以是单个对象
+func loadMachines(path string) ([]machine.MachineInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read machines file: %v", err)
+	}
+
+	var machines []machine.MachineInfo
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var info machine.MachineInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse machines file: %v", err)
+		}
+		machines = append(machines, info)
+	} else if err := json.Unmarshal(data, &machines); err != nil {
+		return nil, fmt.Errorf("failed to parse machines file: %v", err)
+	}
+
+	for i, m := range machines {
+		if m.MAC == "" && m.UUID == "" && m.CPUID == "" {
+			return nil, fmt.Errorf("machine #%d has no MAC, UUID or CPUID", i+1)
+		}
+	}
+
+	return machines, nil
+}
+
+// parseWeights 解析组件权重，格式为 "mac=1,uuid=2,cpuid=2"
+func parseWeights(s string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, item := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q, expected <component>=<weight>", item)
+		}
+		weight, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for %s: %v", name, err)
+		}
+		weights[name] = weight
+	}
+	return weights, nil
+}
@@ -35,33 +35,116 @@ const (
     lkctl get uuid              Get system UUID
     lkctl get cpuid             Get CPU ID
     lkctl get all               Get all machine information
+    lkctl get all --json        Get all machine information as JSON
 
   lkctl gen [options] <output-file> Generate a license
     --mac <mac>                 Specify MAC address
     --uuid <uuid>               Specify system UUID
     --cpuid <cpuid>             Specify CPU ID
+    --machines <file>           JSON list of allowed machines (from 'lkctl get all --json')
+    --max-machines <count>      Maximum number of bound machines (default: number of machines listed)
+    --min-matches <count>       Minimum number of matching machine components (tolerant matching)
+    --min-score <score>         Minimum total weight of matching machine components
+    --weights <list>            Component weights, e.g. mac=1,uuid=2,cpuid=2
     --duration <days>           Validity period (days)
     --customer <name>           Customer name
     --product <name>            Product name
     --version <version>         Product version
     --features <list>           Comma-separated list of features
     --max-users <count>         Maximum number of users
+    --bundle <file>             Multi-product bundle description (YAML)
+    --checkin-url <url>         Subscription license: check-in server URL (requires --lease-days)
+    --lease-days <days>         Subscription license: days a check-in keeps the license valid
+    --require-integrity         Fail verification unless an integrity manifest is configured
+    --lease-key <file>          Floating license: public key of the server that signs seat leases
     --keys-dir <dir>            Directory for key files (default: keys)
     --private-key <file>        Path to private key file. If not provided, a new one is generated.
     --aes-key <file>            Path to AES key file. If not provided, a new one is generated.
 
+  lkctl timestamp [options] <output-file> Issue a signed trusted-time token
+    --private-key <file>        Path to private key file (default: keys/private.pem)
+    --at <time>                 Token time (2006-01-02 or RFC 3339), default: now
+
+  lkctl revoke [options] <license-id> Add a license to the signed revocation list
+    --private-key <file>        Path to private key file (default: keys/private.pem)
+    --crl <file>                Revocation list file, created if missing (default: revocations.json)
+    --reason <text>             Revocation reason
+
+  lkctl request [options] <output-file>  Create an offline activation request on the customer machine
+    --product <name>            Product name
+    --product-version <version> Product version
+    --customer <name>           Customer name
+
+  lkctl fulfill [options] <request-file>  Generate a license bound to the machine in an activation request
+    --private-key <file>        Path to private key file (default: keys/private.pem)
+    --aes-key <file>            Path to AES key file (default: keys/aes.key)
+    --output <file>             Output license file (default: license.lic)
+    --duration <days>           Validity period (default: 365)
+    --customer, --product, --version, --features, --max-users  License fields
+
+  lkctl code [options]          Create an activation code for the activation server
+    --store <file>              Activation code store file (default: activations.json)
+    --product <name>            Product name (required)
+    --max-activations <count>   Maximum number of machines (default: 1)
+    --duration <days>           Validity period of issued licenses (default: 365)
+    --customer, --version, --features, --max-users  License template fields
+
+  lkctl serve [options]         Run the online activation server
+    --addr <addr>               Listen address (default: :8080)
+    --private-key <file>        Path to private key file (default: keys/private.pem)
+    --aes-key <file>            Path to AES key file (default: keys/aes.key)
+    --store <file>              Activation code store file (default: activations.json)
+
+  lkctl floating [options] <license-file>  Run a floating license server limiting concurrent users to max_users
+    --addr <addr>               Listen address (default: :8090)
+    --public-key <file>         Path to public key file (default: keys/public.pem)
+    --aes-key <file>            Path to AES key file (default: keys/aes.key)
+    --product <name>            Only serve licenses covering this product
+    --ttl <duration>            Seat lease duration (default: 5m)
+    --crl <file>                Signed revocation list
+    --lease-key <file>          Private key used to sign seat leases (public key set with 'lkctl gen --lease-key')
+
+  lkctl checkin-server [options]  Run the check-in server for subscription licenses
+    --addr <addr>               Listen address (default: :8081)
+    --private-key <file>        Path to private key file (default: keys/private.pem)
+    --aes-key <file>            Path to AES key file (default: keys/aes.key)
+    --crl <file>                Signed revocation list; revoked licenses are no longer renewed
+
+  lkctl embed [options]         Generate a Go source file embedding the public key and AES key
+    --keys-dir <dir>            Directory for key files (default: keys)
+    --public-key <file>         Path to public key file (overrides --keys-dir)
+    --aes-key <file>            Path to AES key file (overrides --keys-dir)
+    --package <name>            Package name of the generated file (default: main)
+    --out <file>                Output file, - for standard output (default: keys_gen.go)
+    --split <count>             Split the AES key into this many masked shares (default: 3)
+
+  lkctl keyshares [options]     Split the AES key into masked shares for crypto.CombineKey
+    --aes-key <file>            Path to AES key file (default: keys/aes.key)
+    --shares <count>            Number of shares (default: 3)
+    --json                      Output the shares as JSON
+
+  lkctl integrity [options] <executable>...  Sign the hashes of product executables (integrity manifest)
+    --private-key <file>        Path to private key file (default: keys/private.pem)
+    --output <file>             Output manifest file (default: integrity.json)
+    --product, --version        Product (which the license must cover) and version recorded in the manifest
+
   lkctl verify <license-file>   Verify a license
   lkctl info <license-file>     Show license information
 
   lkctl keys                    Generate a new key pair
     --output <dir>              Output directory (default: current directory)
 
+  Global Options:
+    --log-format <text|json>    Write structured audit logs to standard error
+
   lkctl --version               Show version
   lkctl --help                  Show this help message
 `
 )
 
 func main() {
+	parseLogFormat()
+
 	if len(os.Args) < 2 {
 		fmt.Print(Usage)
 		os.Exit(1)
@@ -80,6 +163,28 @@ func main() {
 		handleInfo()
 	case "keys":
 		handleKeys()
+	case "timestamp":
+		handleTimestamp()
+	case "revoke":
+		handleRevoke()
+	case "request":
+		handleRequest()
+	case "fulfill":
+		handleFulfill()
+	case "code":
+		handleCode()
+	case "serve":
+		handleServe()
+	case "floating":
+		handleFloating()
+	case "checkin-server":
+		handleCheckInServer()
+	case "embed":
+		handleEmbed()
+	case "keyshares":
+		handleKeyShares()
+	case "integrity":
+		handleIntegrity()
 	case "--version":
 		fmt.Printf("lkctl version %s\n", Version)
 	case "--help":
@@ -125,6 +230,21 @@ func handleGet() {
 		fmt.Println(cpuid)
 
 	case "all":
+		if len(os.Args) > 3 && os.Args[3] == "--json" {
+			info, err := machine.GetAllInfo()
+			if err != nil {
+				fmt.Printf("Failed to get machine information: %v\n", err)
+				os.Exit(1)
+			}
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				fmt.Printf("Failed to serialize machine information: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
 		mac, err := machine.GetMACAddress()
 		if err != nil {
 			fmt.Printf("MAC: <error: %v>\n", err)
@@ -160,12 +280,22 @@ func handleGen() {
 		mac      = fs.String("mac", "", "MAC address")
 		uuid     = fs.String("uuid", "", "System UUID")
 		cpuid    = fs.String("cpuid", "", "CPU ID")
+		machines = fs.String("machines", "", "JSON list of allowed machines")
+		maxMach  = fs.Int("max-machines", 0, "Maximum number of bound machines")
+		minMatch = fs.Int("min-matches", 0, "Minimum number of matching machine components")
+		minScore = fs.Int("min-score", 0, "Minimum total weight of matching machine components")
+		weights  = fs.String("weights", "", "Component weights, e.g. mac=1,uuid=2,cpuid=2")
 		duration = fs.Int("duration", 365, "Validity period (days)")
 		customer = fs.String("customer", "", "Customer name")
 		product  = fs.String("product", "", "Product name")
 		version  = fs.String("version", "", "Product version")
 		features = fs.String("features", "", "Comma-separated list of features")
 		maxUsers = fs.Int("max-users", 0, "Maximum number of users")
+		bundle   = fs.String("bundle", "", "Multi-product bundle description (YAML)")
+		checkin  = fs.String("checkin-url", "", "Check-in server URL for subscription licenses")
+		lease    = fs.Int("lease-days", 0, "Days a check-in keeps a subscription license valid")
+		requireI = fs.Bool("require-integrity", false, "Fail verification unless an integrity manifest is configured")
+		leaseKey = fs.String("lease-key", "", "Public key of the floating license server that signs seat leases")
 		keysDir  = fs.String("keys-dir", "keys", "Directory to save newly generated key files")
 		privKey  = fs.String("private-key", "", "Path to private key file. If not provided, a new one is generated.")
 		aesKey   = fs.String("aes-key", "", "Path to AES key file. If not provided, a new one is generated.")
@@ -237,6 +367,7 @@ func handleGen() {
 		fmt.Printf("Failed to create generator with keys: %v\n", err)
 		os.Exit(1)
 	}
+	generator.SetLogger(logger)
 
 	// Set generation options
 	options := &license.GenerateOptions{
@@ -248,12 +379,61 @@ func handleGen() {
 		CPUID:        *cpuid,
 		Duration:     time.Duration(*duration) * 24 * time.Hour,
 		MaxUsers:     *maxUsers,
+		MaxMachines:  *maxMach,
+	}
+
+	if *minMatch > 0 || *minScore > 0 || *weights != "" {
+		options.MatchPolicy = &license.MatchPolicy{
+			MinMatches: *minMatch,
+			MinScore:   *minScore,
+		}
+		if *weights != "" {
+			options.MatchPolicy.Weights, err = parseWeights(*weights)
+			if err != nil {
+				fmt.Printf("Failed to parse weights: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if *machines != "" {
+		options.Machines, err = loadMachines(*machines)
+		if err != nil {
+			fmt.Printf("Failed to load machines: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	if *features != "" {
 		options.Features = strings.Split(*features, ",")
 	}
 
+	if *checkin != "" || *lease > 0 {
+		options.CheckIn = &license.CheckInPolicy{URL: *checkin, LeaseDays: *lease}
+	}
+	options.RequireIntegrity = *requireI
+
+	if *leaseKey != "" {
+		keyPEM, err := os.ReadFile(*leaseKey)
+		if err != nil {
+			fmt.Printf("Failed to read lease key: %v\n", err)
+			os.Exit(1)
+		}
+		options.LeaseKey = string(keyPEM)
+	}
+
+	if *bundle != "" {
+		spec, err := loadBundle(*bundle)
+		if err != nil {
+			fmt.Printf("Failed to load bundle: %v\n", err)
+			os.Exit(1)
+		}
+		if err := spec.apply(options, time.Now()); err != nil {
+			fmt.Printf("Failed to apply bundle: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Generate license
 	lic, err := generator.Generate(options)
 	if err != nil {
@@ -311,6 +491,12 @@ func handleGen() {
 
 	fmt.Printf("License ID: %s\n", lic.ID)
 	fmt.Printf("Expires at: %s\n", lic.ExpiresAt.Format("2006-01-02 15:04:05"))
+	for _, grant := range lic.Products {
+		fmt.Printf("Product: %s (expires at %s)\n", grant.Name, grant.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+	if len(lic.Machines) > 0 {
+		fmt.Printf("Bound machines: %d (max %d)\n", len(lic.Machines), lic.MaxMachines)
+	}
 }
 
 func handleVerify() {
@@ -322,7 +508,7 @@ func handleVerify() {
 	licenseFile := os.Args[2]
 
 	// Create verifier
-	verifier, err := license.NewVerifierFromFiles("keys/public.pem", "keys/aes.key")
+	verifier, err := license.NewVerifierFromFiles("keys/public.pem", "keys/aes.key", withLogger()...)
 	if err != nil {
 		fmt.Printf("Failed to create verifier: %v\n", err)
 		fmt.Println("Please make sure the key files exist: keys/public.pem, keys/aes.key")
@@ -349,6 +535,9 @@ func handleVerify() {
 	} else {
 		fmt.Println("✗ License verification failed")
 		fmt.Printf("Error: %s\n", result.Error)
+		for _, c := range result.FailedChecks() {
+			fmt.Printf("  ✗ %s: expected %q, actual %q (%s)\n", c.Name, c.Expected, c.Actual, c.Message)
+		}
 	}
 
 	// Output machine info match status
@@ -371,7 +560,7 @@ func handleInfo() {
 	licenseFile := os.Args[2]
 
 	// Create verifier
-	verifier, err := license.NewVerifierFromFiles("keys/public.pem", "keys/aes.key")
+	verifier, err := license.NewVerifierFromFiles("keys/public.pem", "keys/aes.key", withLogger()...)
 	if err != nil {
 		fmt.Printf("Failed to create verifier: %v\n", err)
 		fmt.Println("Please make sure the key files exist: keys/public.pem, keys/aes.key")
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/activation"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func handleRequest() {
+	fs := flag.NewFlagSet("request", flag.ExitOnError)
+	product := fs.String("product", "", "Product name")
+	version := fs.String("product-version", "", "Product version")
+	customer := fs.String("customer", "", "Customer name")
+	args := parseInterspersed(fs, os.Args[2:])
+	if len(args) == 0 {
+		fmt.Println("Usage: lkctl request [options] <output-file>")
+		os.Exit(1)
+	}
+
+	req, err := activation.NewOfflineRequest(*product, *version, nil)
+	if err != nil {
+		fmt.Printf("Failed to create activation request: %v\n", err)
+		os.Exit(1)
+	}
+	req.CustomerName = *customer
+
+	if err := req.SaveToFile(args[0]); err != nil {
+		fmt.Printf("Failed to save activation request: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Activation request saved to: %s\n", args[0])
+	fmt.Println("Send this file to your license issuer to obtain a license.")
+}
+
+func handleFulfill() {
+	fs := flag.NewFlagSet("fulfill", flag.ExitOnError)
+	privKey := fs.String("private-key", "keys/private.pem", "Path to private key file")
+	aesKey := fs.String("aes-key", "keys/aes.key", "Path to AES key file")
+	output := fs.String("output", "license.lic", "Output license file")
+	duration := fs.Int("duration", 365, "Validity period (days)")
+	customer := fs.String("customer", "", "Customer name (overrides the request)")
+	product := fs.String("product", "", "Product name (overrides the request)")
+	version := fs.String("version", "", "Product version (overrides the request)")
+	features := fs.String("features", "", "Comma-separated list of features")
+	maxUsers := fs.Int("max-users", 0, "Maximum number of users")
+	args := parseInterspersed(fs, os.Args[2:])
+	if len(args) == 0 {
+		fmt.Println("Usage: lkctl fulfill [options] <request-file>")
+		os.Exit(1)
+	}
+
+	req, err := activation.LoadOfflineRequest(args[0])
+	if err != nil {
+		fmt.Printf("Invalid activation request: %v\n", err)
+		os.Exit(1)
+	}
+
+	generator, err := loadGenerator(*privKey, *aesKey)
+	if err != nil {
+		fmt.Printf("Failed to create generator with keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	options := &license.GenerateOptions{
+		ProductName:  *product,
+		Version:      *version,
+		CustomerName: *customer,
+		Duration:     time.Duration(*duration) * 24 * time.Hour,
+		MaxUsers:     *maxUsers,
+	}
+	if *features != "" {
+		options.Features = strings.Split(*features, ",")
+	}
+	req.Apply(options)
+
+	lic, err := generator.Generate(options)
+	if err != nil {
+		fmt.Printf("Failed to generate license: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := generator.SaveToFile(lic, *output); err != nil {
+		fmt.Printf("Failed to save license: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("License generated successfully: %s\n", *output)
+	fmt.Printf("License ID: %s\n", lic.ID)
+	fmt.Printf("Product: %s %s\n", lic.ProductName, lic.Version)
+	fmt.Printf("Bound machine: MAC=%s UUID=%s CPUID=%s\n", lic.MAC, lic.UUID, lic.CPUID)
+	fmt.Printf("Expires at: %s\n", lic.ExpiresAt.Format("2006-01-02 15:04:05"))
+}
+
+// parseInterspersed 解析参数，选项可以出现在位置参数之前或之后（例如 lkctl fulfill req.json --output license.lic），
+// 返回位置参数
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func handleRevoke() {
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	privKey := fs.String("private-key", "keys/private.pem", "Path to private key file")
+	crlPath := fs.String("crl", "revocations.json", "Path to the revocation list file (created if missing)")
+	reason := fs.String("reason", "", "Revocation reason")
+	fs.Parse(os.Args[2:])
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fmt.Println("Usage: lkctl revoke [options] <license-id>")
+		os.Exit(1)
+	}
+
+	// 吊销列表只需要签名，不需要AES密钥
+	generator, err := loadGenerator(*privKey, "")
+	if err != nil {
+		fmt.Printf("Failed to create generator with keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	existing, err := os.ReadFile(*crlPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Failed to read revocation list: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, list, err := generator.Revoke(existing, args[0], *reason)
+	if err != nil {
+		fmt.Printf("Failed to revoke license: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*crlPath, data, 0644); err != nil {
+		fmt.Printf("Failed to save revocation list: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("License revoked: %s\n", args[0])
+	fmt.Printf("Revocation list: %s (sequence %d, %d entries)\n", *crlPath, list.Sequence, len(list.Entries))
+}
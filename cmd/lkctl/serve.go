@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cuilan/license-key-verify/pkg/activation"
+)
+
+func handleServe() {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "Listen address")
+	privKey := fs.String("private-key", "keys/private.pem", "Path to private key file")
+	aesKey := fs.String("aes-key", "keys/aes.key", "Path to AES key file")
+	storePath := fs.String("store", "activations.json", "Activation code store file")
+	fs.Parse(os.Args[2:])
+
+	generator, err := loadGenerator(*privKey, *aesKey)
+	if err != nil {
+		fmt.Printf("Failed to create generator with keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := activation.NewFileStore(*storePath)
+	if err != nil {
+		fmt.Printf("Failed to open activation store: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Activation server listening on %s (store: %s)\n", *addr, *storePath)
+	if err := http.ListenAndServe(*addr, activation.NewServer(generator, store)); err != nil {
+		fmt.Printf("Server error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func handleCode() {
+	fs := flag.NewFlagSet("code", flag.ExitOnError)
+	storePath := fs.String("store", "activations.json", "Activation code store file")
+	product := fs.String("product", "", "Product name")
+	version := fs.String("version", "", "Product version")
+	customer := fs.String("customer", "", "Customer name")
+	features := fs.String("features", "", "Comma-separated list of features")
+	maxUsers := fs.Int("max-users", 0, "Maximum number of users")
+	duration := fs.Int("duration", 365, "Validity period of issued licenses (days)")
+	activations := fs.Int("max-activations", 1, "Maximum number of machines that can be activated")
+	fs.Parse(os.Args[2:])
+
+	if *product == "" {
+		fmt.Println("Usage: lkctl code --product <name> [options]")
+		os.Exit(1)
+	}
+
+	store, err := activation.NewFileStore(*storePath)
+	if err != nil {
+		fmt.Printf("Failed to open activation store: %v\n", err)
+		os.Exit(1)
+	}
+
+	codeValue, err := activation.GenerateCode()
+	if err != nil {
+		fmt.Printf("Failed to generate activation code: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := &activation.Code{
+		Code:           codeValue,
+		ProductName:    *product,
+		Version:        *version,
+		CustomerName:   *customer,
+		MaxUsers:       *maxUsers,
+		DurationDays:   *duration,
+		MaxActivations: *activations,
+	}
+	if *features != "" {
+		code.Features = strings.Split(*features, ",")
+	}
+
+	if err := store.Save(code); err != nil {
+		fmt.Printf("Failed to save activation code: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Activation code: %s\n", code.Code)
+	fmt.Printf("Max activations: %d\n", code.MaxActivations)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+func handleTimestamp() {
+	fs := flag.NewFlagSet("timestamp", flag.ExitOnError)
+	privKey := fs.String("private-key", "keys/private.pem", "Path to private key file")
+	at := fs.String("at", "", "Token time (2006-01-02 or RFC 3339), default: now")
+	fs.Parse(os.Args[2:])
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fmt.Println("Usage: lkctl timestamp [options] <output-file>")
+		os.Exit(1)
+	}
+
+	// 时间令牌只需要签名，不需要AES密钥
+	generator, err := loadGenerator(*privKey, "")
+	if err != nil {
+		fmt.Printf("Failed to create generator with keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	var tokenTime time.Time
+	if *at != "" {
+		tokenTime, err = time.ParseInLocation("2006-01-02", *at, time.Local)
+		if err != nil {
+			tokenTime, err = time.Parse(time.RFC3339, *at)
+		}
+		if err != nil {
+			fmt.Printf("Invalid --at time: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	token, err := generator.IssueTimeToken(tokenTime)
+	if err != nil {
+		fmt.Printf("Failed to issue time token: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(args[0], token, 0644); err != nil {
+		fmt.Printf("Failed to save time token: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Time token generated: %s\n", args[0])
+}
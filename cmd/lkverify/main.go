@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/cuilan/license-key-verify/pkg/license"
 )
@@ -27,11 +32,33 @@ const (
 
   Usage:
   lkverify <license-file> [options]
+  lkverify - [options]            (read the license from standard input)
+  lkverify --license-env <var> [options]
+
+  License Sources (tried in this order, the first one found is used):
+    <license-file>          License file path, or - for standard input
+    --license-env <var>     Read the license from an environment variable
+    --license-base64-env <var>
+                            Read a Base64-encoded license from an environment variable
+    --app <name>            Search ./license.lic, ~/.config/<name>/license.lic
+                            and /etc/<name>/license.lic
 
   Options:
     --keys-dir <directory>  Specify the directory for key files (default: keys)
     --public-key <file>     Specify the path to the public key file (overrides --keys-dir)
     --aes-key <file>        Specify the path to the AES key file (overrides --keys-dir)
+    --product <name>        Only accept licenses granting this product
+    --product-version <v>   Product version to check against the grant (requires --product)
+    --at <date>             Verify as of the given date (2006-01-02 or RFC 3339) instead of now, without clock rollback detection
+    --time-store <file>     Record the last verification time in this file and detect clock rollback
+    --clock-tolerance <d>   Allowed clock rollback with --time-store (default: 10m)
+    --time-token <file>     Trusted-time token (from 'lkctl timestamp') used as a lower bound for now
+    --crl <file>            Signed revocation list (from 'lkctl revoke')
+    --lease <file>          Check-in lease required by subscription licenses
+    --timeout <d>           Abort if reading machine information takes longer (e.g. 10s)
+    --integrity <file>      Integrity manifest (from 'lkctl integrity') the executable must match
+    --executable <file>     Executable checked against --integrity (default: lkverify itself)
+    --log-format <f>        Write a structured audit log (text or json) to standard error
     --json                  Output results in JSON format
     --quiet                 Quiet mode, only outputs exit code
     --version               Show version
@@ -41,20 +68,49 @@ const (
     0  License is valid
     1  License is invalid or other error
     2  Argument error
+    3  License has expired
+    4  License is not yet valid
+    5  Signature or decryption failed (tampered file or wrong keys)
+    6  Machine information does not match
+    7  Unsupported license file format version
+    8  License is not valid for the requested product
+    9  System clock has been rolled back or the time store has been tampered with
+    10 License has been revoked
+    11 Check-in lease is missing or expired (subscription license)
+    12 Verification timed out (see --timeout)
+    13 Executable does not match the integrity manifest
   
   Examples:
     lkverify license.lic
     lkverify license.lic --json
     lkverify license.lic --keys-dir ./mykeys
     lkverify license.lic --public-key /path/to/public.pem --aes-key /path/to/aes.key
+    cat license.lic | lkverify -
+    LICENSE_B64=$(base64 -w0 license.lic) lkverify --license-base64-env LICENSE_B64
+    lkverify --app myapp
 `
 )
 
 type Config struct {
 	LicenseFile   string
+	LicenseEnv    string
+	LicenseB64Env string
+	App           string
 	KeysDir       string
 	PublicKeyPath string
 	AESKeyPath    string
+	Product       string
+	ProductVer    string
+	At            time.Time
+	TimeStore     string
+	Tolerance     time.Duration
+	TimeToken     string
+	CRL           string
+	Lease         string
+	Timeout       time.Duration
+	Integrity     string
+	LogFormat     string
+	Executable    string
 	JSONOutput    bool
 	Quiet         bool
 }
@@ -72,8 +128,58 @@ func main() {
 		aesKeyPath = config.KeysDir + "/aes.key"
 	}
 
+	var opts []license.Option
+	if !config.At.IsZero() {
+		opts = append(opts, license.WithTime(config.At))
+	}
+	if config.TimeToken != "" {
+		token, err := os.ReadFile(config.TimeToken)
+		if err != nil {
+			if !config.Quiet {
+				fmt.Fprintf(os.Stderr, "Failed to read time token: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		opts = append(opts, license.WithTimeToken(token))
+	}
+	if config.CRL != "" {
+		crl, err := os.ReadFile(config.CRL)
+		if err != nil {
+			if !config.Quiet {
+				fmt.Fprintf(os.Stderr, "Failed to read revocation list: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		opts = append(opts, license.WithRevocationList(crl))
+	}
+	if config.Lease != "" {
+		opts = append(opts, license.WithLeaseFile(config.Lease))
+	}
+	if config.Integrity != "" {
+		manifest, err := os.ReadFile(config.Integrity)
+		if err != nil {
+			if !config.Quiet {
+				fmt.Fprintf(os.Stderr, "Failed to read integrity manifest: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		opts = append(opts, license.WithIntegrityManifest(manifest))
+		if config.Executable != "" {
+			opts = append(opts, license.WithExecutable(config.Executable))
+		}
+	}
+	switch config.LogFormat {
+	case "text":
+		opts = append(opts, license.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, nil))))
+	case "json":
+		opts = append(opts, license.WithLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil))))
+	}
+	if config.TimeStore != "" {
+		opts = append(opts, license.WithTimeStore(license.NewFileTimeStore(config.TimeStore, nil), config.Tolerance))
+	}
+
 	// 创建验证器
-	verifier, err := license.NewVerifierFromFiles(publicKeyPath, aesKeyPath)
+	verifier, err := license.NewVerifierFromFiles(publicKeyPath, aesKeyPath, opts...)
 	if err != nil {
 		if !config.Quiet {
 			fmt.Fprintf(os.Stderr, "Failed to create verifier: %v\n", err)
@@ -82,8 +188,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	if config.Product != "" {
+		verifier.SetProduct(config.Product, config.ProductVer)
+	}
+
 	// 验证许可证
-	result, err := verifier.VerifyFile(config.LicenseFile)
+	ctx := context.Background()
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	result, err := verifier.VerifySourceContext(ctx, licenseSource(config))
 	if err != nil {
 		if !config.Quiet {
 			fmt.Fprintf(os.Stderr, "Verification failed: %v\n", err)
@@ -108,16 +225,47 @@ func main() {
 	}
 
 	// 设置退出码
+	os.Exit(exitCode(result))
+}
+
+// exitCode 根据验证结果的错误类型返回退出码
+func exitCode(result *license.VerificationResult) int {
 	if result.Valid {
-		os.Exit(0)
-	} else {
-		os.Exit(1)
+		return 0
+	}
+
+	switch {
+	case errors.Is(result.Err, license.ErrExpired):
+		return 3
+	case errors.Is(result.Err, license.ErrNotYetValid):
+		return 4
+	case errors.Is(result.Err, license.ErrSignature), errors.Is(result.Err, license.ErrDecrypt):
+		return 5
+	case errors.Is(result.Err, license.ErrMachineMismatch):
+		return 6
+	case errors.Is(result.Err, license.ErrUnsupportedVersion):
+		return 7
+	case errors.Is(result.Err, license.ErrProductMismatch):
+		return 8
+	case errors.Is(result.Err, license.ErrClockRollback), errors.Is(result.Err, license.ErrTimeStore):
+		return 9
+	case errors.Is(result.Err, license.ErrRevoked):
+		return 10
+	case errors.Is(result.Err, license.ErrLeaseExpired):
+		return 11
+	case errors.Is(result.Err, license.ErrTimeout):
+		return 12
+	case errors.Is(result.Err, license.ErrIntegrity):
+		return 13
+	default:
+		return 1
 	}
 }
 
 func parseArgs() *Config {
 	config := &Config{
-		KeysDir: "keys",
+		KeysDir:   "keys",
+		Tolerance: license.DefaultClockTolerance,
 	}
 
 	args := os.Args[1:]
@@ -157,8 +305,132 @@ func parseArgs() *Config {
 			}
 			i++
 			config.AESKeyPath = args[i]
+		case "--product":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--product requires a product name\n")
+				os.Exit(2)
+			}
+			i++
+			config.Product = args[i]
+		case "--product-version":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--product-version requires a version\n")
+				os.Exit(2)
+			}
+			i++
+			config.ProductVer = args[i]
+		case "--at":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--at requires a date\n")
+				os.Exit(2)
+			}
+			i++
+			at, err := parseDate(args[i])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid --at date: %v\n", err)
+				os.Exit(2)
+			}
+			config.At = at
+		case "--time-store":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--time-store requires a file path\n")
+				os.Exit(2)
+			}
+			i++
+			config.TimeStore = args[i]
+		case "--time-token":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--time-token requires a file path\n")
+				os.Exit(2)
+			}
+			i++
+			config.TimeToken = args[i]
+		case "--crl":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--crl requires a file path\n")
+				os.Exit(2)
+			}
+			i++
+			config.CRL = args[i]
+		case "--lease":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--lease requires a file path\n")
+				os.Exit(2)
+			}
+			i++
+			config.Lease = args[i]
+		case "--timeout":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--timeout requires a duration\n")
+				os.Exit(2)
+			}
+			i++
+			timeout, err := time.ParseDuration(args[i])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid --timeout: %v\n", err)
+				os.Exit(2)
+			}
+			config.Timeout = timeout
+		case "--log-format":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--log-format requires text or json\n")
+				os.Exit(2)
+			}
+			i++
+			if args[i] != "text" && args[i] != "json" {
+				fmt.Fprintf(os.Stderr, "Invalid --log-format: %s (want text or json)\n", args[i])
+				os.Exit(2)
+			}
+			config.LogFormat = args[i]
+		case "--integrity":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--integrity requires a file path\n")
+				os.Exit(2)
+			}
+			i++
+			config.Integrity = args[i]
+		case "--executable":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--executable requires a file path\n")
+				os.Exit(2)
+			}
+			i++
+			config.Executable = args[i]
+		case "--license-env":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--license-env requires a variable name\n")
+				os.Exit(2)
+			}
+			i++
+			config.LicenseEnv = args[i]
+		case "--license-base64-env":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--license-base64-env requires a variable name\n")
+				os.Exit(2)
+			}
+			i++
+			config.LicenseB64Env = args[i]
+		case "--app":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--app requires an application name\n")
+				os.Exit(2)
+			}
+			i++
+			config.App = args[i]
+		case "--clock-tolerance":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "--clock-tolerance requires a duration\n")
+				os.Exit(2)
+			}
+			i++
+			tolerance, err := time.ParseDuration(args[i])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid --clock-tolerance: %v\n", err)
+				os.Exit(2)
+			}
+			config.Tolerance = tolerance
 		default:
-			if arg[0] == '-' {
+			if arg[0] == '-' && arg != "-" {
 				fmt.Fprintf(os.Stderr, "Unknown option: %s\n", arg)
 				os.Exit(2)
 			} else {
@@ -172,7 +444,17 @@ func parseArgs() *Config {
 		}
 	}
 
-	if config.LicenseFile == "" {
+	if config.ProductVer != "" && config.Product == "" {
+		fmt.Fprintf(os.Stderr, "--product-version requires --product\n")
+		os.Exit(2)
+	}
+
+	if config.Executable != "" && config.Integrity == "" {
+		fmt.Fprintf(os.Stderr, "--executable requires --integrity\n")
+		os.Exit(2)
+	}
+
+	if config.LicenseFile == "" && config.LicenseEnv == "" && config.LicenseB64Env == "" && config.App == "" {
 		fmt.Fprintf(os.Stderr, "A license file must be specified\n")
 		fmt.Print(Usage)
 		os.Exit(2)
@@ -181,6 +463,40 @@ func parseArgs() *Config {
 	return config
 }
 
+// licenseSource 按命令行参数的优先级组合许可证来源
+func licenseSource(config *Config) license.LicenseSource {
+	var sources []license.LicenseSource
+	switch config.LicenseFile {
+	case "":
+	case "-":
+		sources = append(sources, license.ReaderSource(os.Stdin, "standard input"))
+	default:
+		sources = append(sources, license.FileSource(config.LicenseFile))
+	}
+	if config.LicenseEnv != "" {
+		sources = append(sources, license.EnvSource(config.LicenseEnv))
+	}
+	if config.LicenseB64Env != "" {
+		sources = append(sources, license.Base64EnvSource(config.LicenseB64Env))
+	}
+	if config.App != "" {
+		sources = append(sources, license.SearchPath(license.DefaultSearchPath(config.App)...))
+	}
+
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return license.FirstOf(sources...)
+}
+
+// parseDate 解析日期，支持 2006-01-02（本地时区）和 RFC 3339 格式
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
 func printResult(result *license.VerificationResult) {
 	if result.Valid {
 		fmt.Println("✓ License verification passed")
@@ -206,6 +522,13 @@ func printResult(result *license.VerificationResult) {
 				fmt.Printf("Features: %v\n", result.License.Features)
 			}
 
+			if result.Grant != nil {
+				fmt.Printf("Product Grant: %s (expires at %s)\n", result.Grant.Name, result.Grant.ExpiresAt.Format("2006-01-02 15:04:05"))
+				if len(result.Grant.Features) > 0 {
+					fmt.Printf("Product Features: %v\n", result.Grant.Features)
+				}
+			}
+
 			if result.License.MaxUsers > 0 {
 				fmt.Printf("Max Users: %d\n", result.License.MaxUsers)
 			}
@@ -236,5 +559,30 @@ func printResult(result *license.VerificationResult) {
 		if result.MachineInfo.CPUID != "" {
 			fmt.Printf("Current CPUID: %s\n", result.MachineInfo.CPUID)
 		}
+
+		printChecks(result.Checks)
+	}
+}
+
+// printChecks 输出各检查项的结果，便于排查验证失败的原因
+func printChecks(checks []license.CheckResult) {
+	fmt.Println("Checks:")
+	for _, c := range checks {
+		mark := "✓"
+		switch c.Status {
+		case license.CheckFailed:
+			mark = "✗"
+		case license.CheckSkipped:
+			mark = "-"
+		}
+
+		line := fmt.Sprintf("  %s %-10s", mark, c.Name)
+		if c.Expected != "" || c.Actual != "" {
+			line += fmt.Sprintf(" expected: %s, actual: %s", c.Expected, c.Actual)
+		}
+		if c.Message != "" {
+			line += fmt.Sprintf(" (%s)", c.Message)
+		}
+		fmt.Println(strings.TrimRight(line, " "))
 	}
 }
@@ -11,6 +11,8 @@ import (
 // =================================================================
 // 关键安全区：将您的公钥和AES密钥内容作为常量嵌入到代码中
 // 建议从您安全保存的密钥文件中读取内容，然后粘贴到这里。
+// 也可以使用 `lkctl embed --package main --out keys_gen.go` 自动生成嵌入密钥的源文件，
+// 生成的 NewVerifier 可以直接替代下面的 createVerifierFromEmbeddedKeys。
 // =================================================================
 
 const (
@@ -26,6 +28,7 @@ MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAqP4C3nN5Z5vB74rVb5q1
 `
 
 	// aesKeyBase64 应该是从您生成的 aes.key 文件中复制的 Base64 编码字符串。
+	// 明文常量可以被 strings 等工具直接提取，建议改用 `lkctl keyshares` 生成的份额和 crypto.CombineKey。
 	//
 	// !!! 这是一个示例AES密钥，请务必替换为您自己的主AES密钥。!!!
 	aesKeyBase64 = `YOUR_BASE64_ENCODED_AES_KEY_HERE`
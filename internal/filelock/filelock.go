@@ -0,0 +1,43 @@
+// Package filelock 提供跨进程的锁：锁是一个目录，mkdir 是原子操作，
+// 目录存在时间超过 staleAfter 时视为持锁进程已崩溃并强制释放，避免死锁。
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrTimeout 超时仍未获取到锁
+var ErrTimeout = errors.New("timed out waiting for lock")
+
+// retryInterval 锁被占用时的重试间隔
+const retryInterval = 20 * time.Millisecond
+
+// Lock 创建锁目录 path 以获取锁，返回释放锁的函数
+// 超过 timeout 仍未获取到锁时返回 ErrTimeout
+func Lock(path string, timeout, staleAfter time.Duration) (func(), error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := os.Mkdir(path, 0755)
+		if err == nil {
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock: %v", err)
+		}
+
+		// 持锁进程崩溃后锁目录会一直存在
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleAfter {
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w %s", ErrTimeout, path)
+		}
+		time.Sleep(retryInterval)
+	}
+}
@@ -0,0 +1,48 @@
+package filelock_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/internal/filelock"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.lock")
+
+	unlock, err := filelock.Lock(path, time.Second, time.Minute)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	if _, err := filelock.Lock(path, 50*time.Millisecond, time.Minute); !errors.Is(err, filelock.ErrTimeout) {
+		t.Errorf("Lock() while held error = %v, want %v", err, filelock.ErrTimeout)
+	}
+
+	unlock()
+	unlock, err = filelock.Lock(path, 50*time.Millisecond, time.Minute)
+	if err != nil {
+		t.Fatalf("Lock() after unlock error = %v", err)
+	}
+	unlock()
+}
+
+func TestLockStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.lock")
+
+	// 持锁进程崩溃后留下的锁目录
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+
+	unlock, err := filelock.Lock(path, 50*time.Millisecond, time.Minute)
+	if err != nil {
+		t.Fatalf("Lock() with stale lock error = %v", err)
+	}
+	unlock()
+}
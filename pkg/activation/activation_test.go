@@ -0,0 +1,234 @@
+package activation_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/cuilan/license-key-verify/pkg/activation"
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+func TestActivationFlow(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	store := activation.NewMemoryStore(&activation.Code{
+		Code:           "TEST-CODE",
+		ProductName:    "Editor",
+		DurationDays:   30,
+		MaxActivations: 1,
+	})
+	server := httptest.NewServer(activation.NewServer(generator, store))
+	defer server.Close()
+
+	info := licensetest.NewMachineInfo("00:11:22:33:44:55", "uuid-1", "cpuid-1")
+	client := activation.NewClient(server.URL)
+	client.GetMachineInfo = info.GetAllInfo
+
+	resp, err := client.Activate(context.Background(), "TEST-CODE", "Editor", "1.0.0")
+	if err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+	if resp.Remaining != 0 {
+		t.Errorf("Activate() remaining = %d, want 0", resp.Remaining)
+	}
+
+	// 签发的许可证绑定到激活的机器
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(), license.WithMachineInfoProvider(info))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	result, _ := verifier.Verify(resp.License)
+	if !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+	if result.License.MAC != "00:11:22:33:44:55" {
+		t.Errorf("license MAC = %s, want activated machine", result.License.MAC)
+	}
+
+	// 同一台机器重复激活不消耗次数
+	if _, err := client.Activate(context.Background(), "TEST-CODE", "Editor", ""); err != nil {
+		t.Errorf("Activate() on the same machine error = %v", err)
+	}
+
+	info.Set(machine.MachineInfo{MAC: "66:77:88:99:aa:bb"})
+	if _, err := client.Activate(context.Background(), "TEST-CODE", "Editor", ""); !errors.Is(err, activation.ErrNoActivationsLeft) {
+		t.Errorf("Activate() on another machine error = %v, want %v", err, activation.ErrNoActivationsLeft)
+	}
+
+	if _, err := client.Activate(context.Background(), "UNKNOWN", "Editor", ""); !errors.Is(err, activation.ErrCodeNotFound) {
+		t.Errorf("Activate() with unknown code error = %v, want %v", err, activation.ErrCodeNotFound)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codes.json")
+
+	store, err := activation.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.Save(&activation.Code{Code: "A", MaxActivations: 2}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reopened, err := activation.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	code, err := reopened.Get("A")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if code.Remaining() != 2 {
+		t.Errorf("Remaining() = %d, want 2", code.Remaining())
+	}
+}
+
+func TestFileStoreShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codes.json")
+
+	// 例如运行中的 lkctl serve 和新增激活码的 lkctl code
+	server, err := activation.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	cli, err := activation.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	if err := server.Save(&activation.Code{Code: "A", MaxActivations: 1}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := cli.Save(&activation.Code{Code: "B", MaxActivations: 1}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if _, err := server.Get("B"); err != nil {
+		t.Errorf("Get() code added by another store error = %v", err)
+	}
+	if err := server.Save(&activation.Code{Code: "A", MaxActivations: 2}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := cli.Get("B"); err != nil {
+		t.Errorf("Get() after another store saved error = %v", err)
+	}
+	if code, err := cli.Get("A"); err != nil || code.MaxActivations != 2 {
+		t.Errorf("Get() = %+v, %v, want the updated code", code, err)
+	}
+}
+
+func TestActivateSharedFileStore(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "codes.json")
+	store, err := activation.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.Save(&activation.Code{Code: "SHARED", ProductName: "Editor", MaxActivations: 2}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// 两个服务器各自打开同一个存储文件，相当于两个进程
+	servers := make([]*activation.Server, 2)
+	for i := range servers {
+		store, err := activation.NewFileStore(path)
+		if err != nil {
+			t.Fatalf("NewFileStore() error = %v", err)
+		}
+		servers[i] = activation.NewServer(generator, store)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		activated int
+	)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := servers[i%2].Activate(&activation.Request{
+				Code:    "SHARED",
+				Machine: machine.MachineInfo{MAC: fmt.Sprintf("00:11:22:33:44:%02d", i)},
+			})
+			switch {
+			case err == nil:
+				mu.Lock()
+				activated++
+				mu.Unlock()
+			case !errors.Is(err, activation.ErrNoActivationsLeft):
+				t.Errorf("Activate() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	code, err := store.Get("SHARED")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if activated != 2 || len(code.Activations) != 2 {
+		t.Errorf("activated = %d, stored activations = %d, want 2 and 2", activated, len(code.Activations))
+	}
+}
+
+func TestActivateProductVersion(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	store := activation.NewMemoryStore(
+		&activation.Code{Code: "ANY", ProductName: "Editor", MaxActivations: 1},
+		&activation.Code{Code: "PINNED", ProductName: "Editor", Version: "1.0.0", MaxActivations: 1},
+	)
+	server := activation.NewServer(generator, store)
+
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"ANY", "2.1.0"},
+		{"PINNED", "1.0.0"},
+	}
+	for _, tt := range tests {
+		resp, err := server.Activate(&activation.Request{
+			Code:           tt.code,
+			ProductVersion: "2.1.0",
+			Machine:        machine.MachineInfo{MAC: "00:11:22:33:44:55"},
+		})
+		if err != nil {
+			t.Fatalf("Activate(%s) error = %v", tt.code, err)
+		}
+
+		result, _ := verifier.Verify(resp.License)
+		if result.License == nil {
+			t.Fatalf("Verify() error = %s", result.Error)
+		}
+		if result.License.Version != tt.want {
+			t.Errorf("Activate(%s) license version = %q, want %q", tt.code, result.License.Version, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package activation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// Client 产品端使用的激活客户端
+type Client struct {
+	BaseURL    string       // 激活服务器地址，如 https://license.example.com
+	HTTPClient *http.Client // 为 nil 时使用默认客户端（30秒超时）
+
+	// GetMachineInfo 获取本机指纹，为 nil 时使用 machine.GetAllInfo
+	GetMachineInfo func() (*machine.MachineInfo, error)
+}
+
+// NewClient 创建激活客户端
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Activate 使用激活码为本机激活指定产品，返回的 Response.License 即许可证文件内容
+func (c *Client) Activate(ctx context.Context, code, productName, productVersion string) (*Response, error) {
+	getInfo := c.GetMachineInfo
+	if getInfo == nil {
+		getInfo = machine.GetAllInfo
+	}
+
+	info, err := getInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine info: %v", err)
+	}
+
+	return c.Send(ctx, &Request{
+		Code:           code,
+		ProductName:    productName,
+		ProductVersion: productVersion,
+		Machine:        *info,
+	})
+}
+
+// Send 发送激活请求
+func (c *Client) Send(ctx context.Context, req *Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	url := strings.TrimRight(c.BaseURL, "/") + "/v1/activate"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send activation request: %v", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activation response: %v", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, responseError(httpResp.StatusCode, data)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse activation response: %v", err)
+	}
+	return &resp, nil
+}
+
+// responseError 将错误响应转换为对应的错误类型
+func responseError(status int, data []byte) error {
+	var errResp errorResponse
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+		message = errResp.Error
+	}
+
+	var sentinel error
+	switch status {
+	case http.StatusBadRequest:
+		sentinel = ErrInvalidRequest
+	case http.StatusNotFound:
+		sentinel = ErrCodeNotFound
+	case http.StatusForbidden:
+		sentinel = ErrProductMismatch
+	case http.StatusConflict:
+		sentinel = ErrNoActivationsLeft
+	default:
+		return fmt.Errorf("%w (HTTP %d): %s", ErrActivationRejected, status, message)
+	}
+
+	if message == sentinel.Error() {
+		return sentinel
+	}
+	return fmt.Errorf("%w: %s", sentinel, strings.TrimPrefix(message, sentinel.Error()+": "))
+}
@@ -0,0 +1,145 @@
+package activation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// OfflineRequestVersion 离线激活请求文件的格式版本
+const OfflineRequestVersion = "1.0"
+
+// ErrChecksumMismatch 离线激活请求文件的校验和不匹配，文件在传输中损坏或被手工修改
+var ErrChecksumMismatch = errors.New("activation request checksum mismatch")
+
+// OfflineRequest 离线激活请求
+// 在客户机器上生成，通过文件交给签发方，签发方据此生成绑定该机器的许可证。
+// 请求文件不签名，只带有校验和用于发现传输损坏，签发方应自行核对其中的产品信息。
+type OfflineRequest struct {
+	ProductName    string              `json:"product_name,omitempty"`    // 请求激活的产品
+	ProductVersion string              `json:"product_version,omitempty"` // 产品版本
+	CustomerName   string              `json:"customer_name,omitempty"`   // 客户名称
+	Machine        machine.MachineInfo `json:"machine"`                   // 机器指纹
+	CreatedAt      time.Time           `json:"created_at"`                // 生成时间
+	Version        string              `json:"version"`                   // 文件格式版本
+	Checksum       string              `json:"checksum"`                  // SHA-256 校验和
+}
+
+// NewOfflineRequest 创建离线激活请求，info 为 nil 时读取本机的机器信息
+func NewOfflineRequest(productName, productVersion string, info *machine.MachineInfo) (*OfflineRequest, error) {
+	if info == nil {
+		var err error
+		info, err = machine.GetAllInfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get machine info: %v", err)
+		}
+	}
+
+	return &OfflineRequest{
+		ProductName:    productName,
+		ProductVersion: productVersion,
+		Machine:        *info,
+		CreatedAt:      time.Now().UTC(),
+		Version:        OfflineRequestVersion,
+	}, nil
+}
+
+// Encode 计算校验和并序列化请求文件
+func (r *OfflineRequest) Encode() ([]byte, error) {
+	checksum, err := r.checksum()
+	if err != nil {
+		return nil, err
+	}
+	r.Checksum = checksum
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal activation request: %v", err)
+	}
+	return data, nil
+}
+
+// SaveToFile 将请求保存到文件
+func (r *OfflineRequest) SaveToFile(path string) error {
+	data, err := r.Encode()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write activation request: %v", err)
+	}
+	return nil
+}
+
+// ParseOfflineRequest 解析请求文件并校验校验和
+func ParseOfflineRequest(data []byte) (*OfflineRequest, error) {
+	var r OfflineRequest
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	if r.Version != OfflineRequestVersion {
+		return nil, fmt.Errorf("%w: unsupported request format version: %s", ErrInvalidRequest, r.Version)
+	}
+
+	checksum, err := r.checksum()
+	if err != nil {
+		return nil, err
+	}
+	if r.Checksum != checksum {
+		return nil, ErrChecksumMismatch
+	}
+
+	if r.Machine.MAC == "" && r.Machine.UUID == "" && r.Machine.CPUID == "" {
+		return nil, fmt.Errorf("%w: machine fingerprint is required", ErrInvalidRequest)
+	}
+
+	return &r, nil
+}
+
+// LoadOfflineRequest 从文件读取并解析请求
+func LoadOfflineRequest(path string) (*OfflineRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activation request: %v", err)
+	}
+	return ParseOfflineRequest(data)
+}
+
+// Apply 将请求中的产品和机器信息填入生成选项，许可证将绑定请求中的机器
+// options 中已设置的产品名称、版本和客户名称优先于请求中的值
+func (r *OfflineRequest) Apply(options *license.GenerateOptions) {
+	if options.ProductName == "" {
+		options.ProductName = r.ProductName
+	}
+	if options.Version == "" {
+		options.Version = r.ProductVersion
+	}
+	if options.CustomerName == "" {
+		options.CustomerName = r.CustomerName
+	}
+	options.MAC = r.Machine.MAC
+	options.UUID = r.Machine.UUID
+	options.CPUID = r.Machine.CPUID
+}
+
+// checksum 计算除校验和字段外的请求内容的 SHA-256
+func (r *OfflineRequest) checksum() (string, error) {
+	content := *r
+	content.Checksum = ""
+
+	data, err := json.Marshal(&content)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal activation request: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,68 @@
+package activation_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cuilan/license-key-verify/pkg/activation"
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+func TestOfflineRequest(t *testing.T) {
+	info := licensetest.NewMachineInfo("00:11:22:33:44:55", "uuid-1", "cpuid-1")
+	machineInfo, _ := info.GetAllInfo()
+
+	req, err := activation.NewOfflineRequest("Editor", "1.2.0", machineInfo)
+	if err != nil {
+		t.Fatalf("NewOfflineRequest() error = %v", err)
+	}
+	data, err := req.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	parsed, err := activation.ParseOfflineRequest(data)
+	if err != nil {
+		t.Fatalf("ParseOfflineRequest() error = %v", err)
+	}
+	if parsed.Machine != *machineInfo || parsed.ProductName != "Editor" {
+		t.Errorf("ParseOfflineRequest() = %+v, want original request", parsed)
+	}
+
+	// 修改请求内容后校验和不再匹配
+	tampered := bytes.Replace(data, []byte("uuid-1"), []byte("uuid-2"), 1)
+	if _, err := activation.ParseOfflineRequest(tampered); !errors.Is(err, activation.ErrChecksumMismatch) {
+		t.Errorf("ParseOfflineRequest() tampered error = %v, want %v", err, activation.ErrChecksumMismatch)
+	}
+
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	options := &license.GenerateOptions{}
+	parsed.Apply(options)
+	lic, err := generator.Generate(options)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	licenseData, _ := generator.Encode(lic)
+
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(),
+		license.WithMachineInfoProvider(info), license.WithProduct("Editor", "1.2.0"))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	if result, _ := verifier.Verify(licenseData); !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+
+	// 其他机器上无法使用
+	info.Set(machine.MachineInfo{MAC: "66:77:88:99:aa:bb", UUID: "uuid-2", CPUID: "cpuid-1"})
+	if result, _ := verifier.Verify(licenseData); result.Valid {
+		t.Error("Verify() should reject the license on another machine")
+	}
+}
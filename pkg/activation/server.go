@@ -0,0 +1,157 @@
+package activation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// maxRequestSize 请求体的最大字节数
+const maxRequestSize = 64 << 10
+
+// Server 在线激活服务器
+// 持有签发密钥，客户端提交激活码和机器指纹，服务器检查剩余激活次数后返回绑定该机器的许可证
+type Server struct {
+	generator *license.Generator
+	store     Store
+	mux       *http.ServeMux
+}
+
+// NewServer 创建激活服务器
+func NewServer(generator *license.Generator, store Store) *Server {
+	s := &Server{
+		generator: generator,
+		store:     store,
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("POST /v1/activate", s.handleActivate)
+	return s
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Activate 处理激活请求
+// 同一台机器重复激活时重新签发许可证，不消耗激活次数
+func (s *Server) Activate(req *Request) (*Response, error) {
+	if req.Code == "" {
+		return nil, fmt.Errorf("%w: activation code is required", ErrInvalidRequest)
+	}
+	if req.Machine.MAC == "" && req.Machine.UUID == "" && req.Machine.CPUID == "" {
+		return nil, fmt.Errorf("%w: machine fingerprint is required", ErrInvalidRequest)
+	}
+
+	// 检查剩余次数、签发许可证和记录激活在同一次 Update 中完成，避免并发激活超出次数
+	var resp *Response
+	err := s.store.Update(req.Code, func(code *Code) error {
+		if req.ProductName != "" && req.ProductName != code.ProductName {
+			return ErrProductMismatch
+		}
+
+		existing := code.findActivation(req.Machine)
+		if existing == nil && code.Remaining() == 0 {
+			return ErrNoActivationsLeft
+		}
+
+		// 激活码未指定版本时使用请求中的版本
+		version := code.Version
+		if version == "" {
+			version = req.ProductVersion
+		}
+
+		lic, err := s.generator.Generate(&license.GenerateOptions{
+			ProductName:  code.ProductName,
+			Version:      version,
+			CustomerName: code.CustomerName,
+			MAC:          req.Machine.MAC,
+			UUID:         req.Machine.UUID,
+			CPUID:        req.Machine.CPUID,
+			Duration:     time.Duration(code.DurationDays) * 24 * time.Hour,
+			Features:     code.Features,
+			MaxUsers:     code.MaxUsers,
+			Notes:        "activation code " + code.Code,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate license: %v", err)
+		}
+
+		data, err := s.generator.Encode(lic)
+		if err != nil {
+			return fmt.Errorf("failed to encode license: %v", err)
+		}
+
+		if existing != nil {
+			existing.LicenseID = lic.ID
+			existing.ActivatedAt = lic.IssuedAt
+		} else {
+			code.Activations = append(code.Activations, Activation{
+				LicenseID:   lic.ID,
+				Machine:     req.Machine,
+				ActivatedAt: lic.IssuedAt,
+			})
+		}
+
+		resp = &Response{
+			License:   data,
+			LicenseID: lic.ID,
+			ExpiresAt: lic.ExpiresAt,
+			Remaining: code.Remaining(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// handleActivate 处理 POST /v1/activate
+func (s *Server) handleActivate(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	resp, err := s.Activate(&req)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// statusFor 返回错误对应的HTTP状态码
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrCodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrProductMismatch):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNoActivationsLeft):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出错误响应
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &errorResponse{Error: message})
+}
@@ -0,0 +1,231 @@
+package activation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cuilan/license-key-verify/internal/filelock"
+)
+
+// Store 激活码存储
+type Store interface {
+	// Get 返回激活码，不存在时返回 ErrCodeNotFound
+	Get(code string) (*Code, error)
+	// Save 保存（新增或更新）激活码
+	Save(code *Code) error
+	// Update 原子地读取、修改并保存激活码：fn 返回错误时不保存，激活码不存在时返回 ErrCodeNotFound
+	// 服务器通过 Update 处理激活，实现需要保证同一激活码的并发 Update 不会相互覆盖
+	Update(code string, fn func(*Code) error) error
+}
+
+// MemoryStore 内存中的激活码存储，适用于测试
+type MemoryStore struct {
+	mu    sync.Mutex
+	codes map[string]*Code
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore(codes ...*Code) *MemoryStore {
+	s := &MemoryStore{codes: make(map[string]*Code)}
+	for _, c := range codes {
+		s.codes[c.Code] = cloneCode(c)
+	}
+	return s
+}
+
+// Get 返回激活码的副本
+func (s *MemoryStore) Get(code string) (*Code, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.codes[code]
+	if !ok {
+		return nil, ErrCodeNotFound
+	}
+	return cloneCode(c), nil
+}
+
+// Save 保存激活码的副本
+func (s *MemoryStore) Save(code *Code) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.codes[code.Code] = cloneCode(code)
+	return nil
+}
+
+// Update 在锁内修改激活码的副本，fn 成功后保存
+func (s *MemoryStore) Update(code string, fn func(*Code) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.codes[code]
+	if !ok {
+		return ErrCodeNotFound
+	}
+	c = cloneCode(c)
+	if err := fn(c); err != nil {
+		return err
+	}
+	s.codes[code] = c
+	return nil
+}
+
+// 文件锁参数
+const (
+	storeLockTimeout    = 10 * time.Second // 获取锁的超时时间
+	storeLockStaleAfter = 30 * time.Second // 锁目录存在超过该时间视为持锁进程已崩溃
+)
+
+// FileStore 基于JSON文件的激活码存储
+// 每次 Get、Save 和 Update 都在文件锁内重新读取文件，可以与其他进程（例如运行中的 lkctl serve 和 lkctl code）共享同一个文件；
+// 文件锁是与存储文件同名加 .lock 后缀的目录（mkdir 是原子操作）
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore 打开激活码存储文件，文件不存在时创建空存储
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 读取文件并返回激活码
+func (s *FileStore) Get(code string) (*Code, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	codes, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := codes[code]
+	if !ok {
+		return nil, ErrCodeNotFound
+	}
+	return c, nil
+}
+
+// Save 重新读取文件，保存激活码后写回，不影响其他进程写入的激活码
+func (s *FileStore) Save(code *Code) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	codes, err := s.load()
+	if err != nil {
+		return err
+	}
+	codes[code.Code] = cloneCode(code)
+	return s.flush(codes)
+}
+
+// Update 在文件锁内读取激活码，fn 成功后写回，其他进程不会在读取和写回之间修改文件
+func (s *FileStore) Update(code string, fn func(*Code) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	codes, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	c, ok := codes[code]
+	if !ok {
+		return ErrCodeNotFound
+	}
+	if err := fn(c); err != nil {
+		return err
+	}
+	return s.flush(codes)
+}
+
+// load 读取存储文件，文件不存在时返回空存储
+func (s *FileStore) load() (map[string]*Code, error) {
+	codes := make(map[string]*Code)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return codes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store file: %v", err)
+	}
+
+	var list []*Code
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse store file: %v", err)
+	}
+	for _, c := range list {
+		codes[c.Code] = c
+	}
+	return codes, nil
+}
+
+// flush 按激活码排序后写入文件，先写入临时文件再重命名
+func (s *FileStore) flush(codes map[string]*Code) error {
+	list := make([]*Code, 0, len(codes))
+	for _, c := range codes {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal store: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write store file: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write store file: %v", err)
+	}
+	return nil
+}
+
+// lock 获取存储文件的锁，返回释放锁的函数
+func (s *FileStore) lock() (func(), error) {
+	unlock, err := filelock.Lock(s.path+".lock", storeLockTimeout, storeLockStaleAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock store: %w", err)
+	}
+	return unlock, nil
+}
+
+// cloneCode 复制激活码，避免调用方修改存储中的数据
+func cloneCode(c *Code) *Code {
+	clone := *c
+	clone.Features = append([]string(nil), c.Features...)
+	clone.Activations = append([]Activation(nil), c.Activations...)
+	return &clone
+}
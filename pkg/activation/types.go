@@ -0,0 +1,99 @@
+package activation
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// 激活失败的错误类型
+var (
+	ErrCodeNotFound       = errors.New("activation code not found")
+	ErrNoActivationsLeft  = errors.New("no activations left for this code")
+	ErrProductMismatch    = errors.New("activation code is not valid for this product")
+	ErrInvalidRequest     = errors.New("invalid activation request")
+	ErrActivationRejected = errors.New("activation rejected by server")
+)
+
+// Code 激活码及其对应的许可证模板
+type Code struct {
+	Code           string       `json:"code"`                    // 激活码
+	ProductName    string       `json:"product_name"`            // 产品名称
+	Version        string       `json:"version,omitempty"`       // 产品版本
+	CustomerName   string       `json:"customer_name,omitempty"` // 客户名称
+	Features       []string     `json:"features,omitempty"`      // 允许的功能列表
+	MaxUsers       int          `json:"max_users,omitempty"`     // 最大用户数
+	DurationDays   int          `json:"duration_days"`           // 许可证有效期（天）
+	MaxActivations int          `json:"max_activations"`         // 最大激活次数（机器数）
+	Activations    []Activation `json:"activations,omitempty"`   // 已激活的机器
+}
+
+// Activation 一次激活记录
+type Activation struct {
+	LicenseID   string              `json:"license_id"`   // 签发的许可证ID
+	Machine     machine.MachineInfo `json:"machine"`      // 激活的机器
+	ActivatedAt time.Time           `json:"activated_at"` // 激活时间
+}
+
+// Remaining 返回剩余的激活次数
+func (c *Code) Remaining() int {
+	if remaining := c.MaxActivations - len(c.Activations); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// findActivation 查找同一台机器的激活记录
+func (c *Code) findActivation(info machine.MachineInfo) *Activation {
+	for i := range c.Activations {
+		if c.Activations[i].Machine == info {
+			return &c.Activations[i]
+		}
+	}
+	return nil
+}
+
+// Request 激活请求
+type Request struct {
+	Code           string              `json:"code"`                      // 激活码
+	ProductName    string              `json:"product_name,omitempty"`    // 请求激活的产品，为空时不检查
+	ProductVersion string              `json:"product_version,omitempty"` // 产品版本，激活码未指定版本时写入许可证
+	Machine        machine.MachineInfo `json:"machine"`                   // 机器指纹
+}
+
+// Response 激活响应
+type Response struct {
+	License   []byte    `json:"license"`               // 许可证文件内容
+	LicenseID string    `json:"license_id"`            // 许可证ID
+	ExpiresAt time.Time `json:"expires_at"`            // 过期时间
+	Remaining int       `json:"remaining_activations"` // 剩余的激活次数
+}
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// codeAlphabet 激活码字符集，去掉了容易混淆的 0/O、1/I
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateCode 生成形如 XXXX-XXXX-XXXX-XXXX 的随机激活码
+func GenerateCode() (string, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate activation code: %v", err)
+	}
+
+	var b strings.Builder
+	for i, r := range randomBytes {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(codeAlphabet[int(r)%len(codeAlphabet)])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,144 @@
+package checkin_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/checkin"
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+)
+
+func TestCheckInFlow(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	var logs bytes.Buffer
+	generator.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	crlPath := filepath.Join(t.TempDir(), "revocations.json")
+	server, err := checkin.NewServer(generator, checkin.WithRevocationListFile(crlPath))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// 先写入一个不包含该许可证的吊销列表
+	crl, _, err := generator.Revoke(nil, "another-license", "")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	os.WriteFile(crlPath, crl, 0644)
+
+	info := licensetest.NewMachineInfo("00:11:22:33:44:55", "uuid-1", "cpuid-1")
+	lic, err := generator.Generate(&license.GenerateOptions{
+		MAC:     "00:11:22:33:44:55",
+		CheckIn: &license.CheckInPolicy{URL: httpServer.URL + "/v1/checkin", LeaseDays: 7},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	data, _ := generator.Encode(lic)
+
+	leasePath := filepath.Join(t.TempDir(), "lease.json")
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	observed := 0
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(),
+		license.WithMachineInfoProvider(info), license.WithLeaseFile(leasePath),
+		license.WithObserver(func(*license.VerificationResult) { observed++ }))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	if result, _ := verifier.Verify(data); !errors.Is(result.Err, license.ErrLeaseExpired) {
+		t.Fatalf("Verify() before check-in Err = %v, want %v", result.Err, license.ErrLeaseExpired)
+	}
+
+	client := checkin.NewClient(verifier, leasePath)
+	client.GetMachineInfo = info.GetAllInfo
+	observed = 0
+	lease, err := client.CheckIn(context.Background(), data)
+	if err != nil {
+		t.Fatalf("CheckIn() error = %v", err)
+	}
+	// 签到只解出许可证，不产生验证记录
+	if observed != 0 {
+		t.Errorf("CheckIn() notified verifier observers %d times, want 0", observed)
+	}
+	if lease.ExpiresAt.Sub(lease.IssuedAt) != 7*24*time.Hour {
+		t.Errorf("CheckIn() lease = %v to %v, want 7 days", lease.IssuedAt, lease.ExpiresAt)
+	}
+
+	if result, _ := verifier.Verify(data); !result.Valid {
+		t.Fatalf("Verify() after check-in invalid: %s", result.Error)
+	}
+
+	// 许可证绑定的机器与上报的机器不一致
+	other := licensetest.NewMachineInfo("66:77:88:99:aa:bb", "uuid-2", "cpuid-2")
+	client.GetMachineInfo = other.GetAllInfo
+	if _, err := client.CheckIn(context.Background(), data); !errors.Is(err, checkin.ErrLicenseRejected) {
+		t.Errorf("CheckIn() from another machine error = %v, want %v", err, checkin.ErrLicenseRejected)
+	}
+	client.GetMachineInfo = info.GetAllInfo
+
+	// 被拒绝的签到不签发租约
+	if n := strings.Count(logs.String(), "lease issued"); n != 1 {
+		t.Errorf("generator logged %d issued leases, want 1", n)
+	}
+
+	// 取消订阅后不再续期
+	crl, _, err = generator.Revoke(crl, lic.ID, "subscription cancelled")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	os.WriteFile(crlPath, crl, 0644)
+
+	if _, err := client.CheckIn(context.Background(), data); !errors.Is(err, license.ErrRevoked) {
+		t.Errorf("CheckIn() revoked error = %v, want %v", err, license.ErrRevoked)
+	}
+}
+
+func TestCheckInRequireIntegrity(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	server, err := checkin.NewServer(generator)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// 完整性只能在客户端检查，签到服务器没有完整性清单
+	lic, err := generator.Generate(&license.GenerateOptions{
+		CheckIn:          &license.CheckInPolicy{URL: httpServer.URL + "/v1/checkin", LeaseDays: 7},
+		RequireIntegrity: true,
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	data, _ := generator.Encode(lic)
+
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	client := checkin.NewClient(verifier, filepath.Join(t.TempDir(), "lease.json"))
+	if _, err := client.CheckIn(context.Background(), data); err != nil {
+		t.Errorf("CheckIn() error = %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package checkin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// Client 产品端使用的签到客户端
+type Client struct {
+	Verifier   *license.Verifier // 用于解出许可证中的签到地址及校验返回的租约
+	LeasePath  string            // 租约保存路径，与验证器的 license.WithLeaseFile 使用同一路径
+	HTTPClient *http.Client      // 为 nil 时使用默认客户端（30秒超时）
+
+	// GetMachineInfo 获取本机指纹，为 nil 时使用 machine.GetAllInfo
+	GetMachineInfo func() (*machine.MachineInfo, error)
+
+	// OnError Run 中签到失败时调用，为 nil 时忽略错误并在下个周期重试
+	OnError func(error)
+}
+
+// NewClient 创建签到客户端
+func NewClient(verifier *license.Verifier, leasePath string) *Client {
+	return &Client{Verifier: verifier, LeasePath: leasePath}
+}
+
+// CheckIn 签到一次，成功后将租约保存到 LeasePath
+func (c *Client) CheckIn(ctx context.Context, licenseData []byte) (*license.Lease, error) {
+	lic, err := c.license(licenseData)
+	if err != nil {
+		return nil, err
+	}
+
+	getInfo := c.GetMachineInfo
+	if getInfo == nil {
+		getInfo = machine.GetAllInfo
+	}
+	info, err := getInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine info: %v", err)
+	}
+
+	resp, err := c.send(ctx, lic.CheckIn.URL, &Request{License: licenseData, Machine: *info})
+	if err != nil {
+		return nil, err
+	}
+
+	lease, err := c.Verifier.ParseLease(resp.Lease)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid lease from server: %v", ErrCheckInFailed, err)
+	}
+	if lease.LicenseID != lic.ID {
+		return nil, fmt.Errorf("%w: lease was issued for another license", ErrCheckInFailed)
+	}
+
+	if err := writeFile(c.LeasePath, resp.Lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Run 立即签到一次，之后每隔 interval 签到，直到 ctx 取消
+// interval 为 0 时使用租约有效期的四分之一；签到失败时调用 OnError 并在下个周期重试
+func (c *Client) Run(ctx context.Context, licenseData []byte, interval time.Duration) error {
+	if interval <= 0 {
+		lic, err := c.license(licenseData)
+		if err != nil {
+			return err
+		}
+		interval = lic.CheckIn.LeaseDuration() / 4
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.CheckIn(ctx, licenseData); err != nil && c.OnError != nil {
+			c.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// license 解出许可证，只要求签名和解密通过（租约过期正是需要签到的原因）
+// 使用 Decode 而不是 Verify，避免每次签到都记录一次验证失败
+func (c *Client) license(licenseData []byte) (*license.License, error) {
+	lic, err := c.Verifier.Decode(licenseData)
+	if err != nil {
+		return nil, err
+	}
+	if lic.CheckIn == nil {
+		return nil, fmt.Errorf("license does not require check-in")
+	}
+	return lic, nil
+}
+
+// send 发送签到请求
+func (c *Client) send(ctx context.Context, url string, req *Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCheckInFailed, err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read response: %v", ErrCheckInFailed, err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, responseError(httpResp.StatusCode, data)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse response: %v", ErrCheckInFailed, err)
+	}
+	return &resp, nil
+}
+
+// responseError 将错误响应转换为对应的错误类型
+func responseError(status int, data []byte) error {
+	var errResp errorResponse
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+		message = errResp.Error
+	}
+
+	var sentinel error
+	switch status {
+	case http.StatusBadRequest:
+		sentinel = ErrInvalidRequest
+	case http.StatusForbidden:
+		sentinel = license.ErrRevoked
+	case http.StatusUnprocessableEntity:
+		sentinel = ErrLicenseRejected
+	default:
+		return fmt.Errorf("%w (HTTP %d): %s", ErrCheckInFailed, status, message)
+	}
+
+	if message == sentinel.Error() {
+		return sentinel
+	}
+	return fmt.Errorf("%w: %s", sentinel, strings.TrimPrefix(message, sentinel.Error()+": "))
+}
+
+// writeFile 原子地写入文件
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lease: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write lease: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package checkin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// maxRequestSize 请求体的最大字节数
+const maxRequestSize = 256 << 10
+
+// Server 签到服务器
+// 持有签发密钥，按客户端上报的机器信息完整验证许可证，验证通过时签发新的租约
+type Server struct {
+	generator      *license.Generator
+	publicKeyPEM   []byte
+	revocationFile string
+	mux            *http.ServeMux
+}
+
+// ServerOption 签到服务器选项
+type ServerOption func(*Server)
+
+// WithRevocationListFile 每次签到时读取吊销列表（由 lkctl revoke 生成），被吊销的许可证不再续期
+func WithRevocationListFile(path string) ServerOption {
+	return func(s *Server) {
+		s.revocationFile = path
+	}
+}
+
+// NewServer 创建签到服务器
+func NewServer(generator *license.Generator, opts ...ServerOption) (*Server, error) {
+	publicKeyPEM, err := generator.GetPublicKeyPEM()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key: %v", err)
+	}
+
+	s := &Server{
+		generator:    generator,
+		publicKeyPEM: publicKeyPEM,
+		mux:          http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("POST /v1/checkin", s.handleCheckIn)
+	return s, nil
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// CheckIn 处理签到请求
+func (s *Server) CheckIn(req *Request) (*Response, error) {
+	if len(req.License) == 0 {
+		return nil, fmt.Errorf("%w: license is required", ErrInvalidRequest)
+	}
+
+	reported := req.Machine
+	opts := []license.Option{
+		license.WithMachineInfoProvider(license.MachineInfoFunc(func() (*machine.MachineInfo, error) {
+			return &reported, nil
+		})),
+	}
+
+	if s.revocationFile != "" {
+		data, err := os.ReadFile(s.revocationFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read revocation list: %v", err)
+		}
+		opts = append(opts, license.WithRevocationList(data))
+	}
+
+	verifier, err := license.NewVerifier(s.publicKeyPEM, s.generator.GetAESKey(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create verifier: %v", err)
+	}
+
+	// 先在没有租约的情况下完整验证，除签到租约外的检查项都通过后才签发租约
+	// 可执行文件完整性只能在客户端检查，服务器不检查
+	result, _ := verifier.Verify(req.License)
+	if result.License == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, result.Error)
+	}
+	if result.License.CheckIn == nil {
+		return nil, fmt.Errorf("%w: license does not require check-in", ErrInvalidRequest)
+	}
+	if errors.Is(result.Err, license.ErrRevoked) {
+		return nil, result.Err
+	}
+	for _, check := range result.FailedChecks() {
+		if check.Name != license.CheckLease && check.Name != license.CheckIntegrity {
+			return nil, fmt.Errorf("%w: %s", ErrLicenseRejected, check.Message)
+		}
+	}
+
+	data, lease, err := s.generator.IssueLease(result.License)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue lease: %v", err)
+	}
+
+	return &Response{Lease: data, ExpiresAt: lease.ExpiresAt}, nil
+}
+
+// handleCheckIn 处理 POST /v1/checkin
+func (s *Server) handleCheckIn(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	resp, err := s.CheckIn(&req)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// statusFor 返回错误对应的HTTP状态码
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, license.ErrRevoked):
+		return http.StatusForbidden
+	case errors.Is(err, ErrLicenseRejected):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出错误响应
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &errorResponse{Error: message})
+}
@@ -0,0 +1,35 @@
+// Package checkin 实现订阅许可证的定期签到：
+// 客户端定期将许可证提交到许可证中的签到地址，签到服务器验证许可证未被吊销后签发有时限的租约，
+// 客户端将租约保存在本地，验证器通过 license.WithLeaseFile 读取该租约。
+package checkin
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// 签到失败的错误类型，吊销的许可证返回 license.ErrRevoked
+var (
+	ErrInvalidRequest  = errors.New("invalid check-in request")
+	ErrLicenseRejected = errors.New("license was rejected by the check-in server")
+	ErrCheckInFailed   = errors.New("check-in failed")
+)
+
+// Request 签到请求
+type Request struct {
+	License []byte              `json:"license"` // 许可证文件内容
+	Machine machine.MachineInfo `json:"machine"` // 机器指纹
+}
+
+// Response 签到响应
+type Response struct {
+	Lease     []byte    `json:"lease"`      // 签名的租约
+	ExpiresAt time.Time `json:"expires_at"` // 租约过期时间
+}
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Error string `json:"error"`
+}
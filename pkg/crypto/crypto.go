@@ -4,11 +4,13 @@ import (
 	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"io"
@@ -70,6 +72,17 @@ func (kp *KeyPair) PublicKeyToPEM() ([]byte, error) {
 	return publicKeyPEM, nil
 }
 
+// KeyID 计算公钥的标识：DER编码的SHA-256前8字节的十六进制，用于确认两端使用的是同一对密钥
+func KeyID(publicKey *rsa.PublicKey) (string, error) {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %v", err)
+	}
+
+	sum := sha256.Sum256(publicKeyBytes)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
 // LoadPrivateKeyFromPEM 从PEM格式加载私钥
 func LoadPrivateKeyFromPEM(pemData []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(pemData)
@@ -194,6 +207,23 @@ func GenerateAESKey() ([]byte, error) {
 	return key, nil
 }
 
+// ComputeHMAC 计算HMAC-SHA256
+func ComputeHMAC(data []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// VerifyHMAC 校验HMAC-SHA256，使用常量时间比较
+func VerifyHMAC(data []byte, expectedMAC []byte, key []byte) bool {
+	return hmac.Equal(ComputeHMAC(data, key), expectedMAC)
+}
+
+// DeriveKey 从主密钥派生指定用途的子密钥，避免同一密钥用于不同用途
+func DeriveKey(masterKey []byte, purpose string) []byte {
+	return ComputeHMAC([]byte(purpose), masterKey)
+}
+
 // EncodeBase64 Base64编码
 func EncodeBase64(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
@@ -96,3 +96,49 @@ func TestRSASignVerify(t *testing.T) {
 		t.Error("VerifySignature() should fail with wrong data")
 	}
 }
+
+func TestHMAC(t *testing.T) {
+	key := DeriveKey([]byte("master key"), "test")
+	data := []byte("last seen: 2024-01-01T00:00:00Z")
+
+	mac := ComputeHMAC(data, key)
+	if !VerifyHMAC(data, mac, key) {
+		t.Error("VerifyHMAC() should succeed with correct data")
+	}
+
+	if VerifyHMAC([]byte("tampered"), mac, key) {
+		t.Error("VerifyHMAC() should fail with wrong data")
+	}
+
+	if VerifyHMAC(data, mac, DeriveKey([]byte("master key"), "other")) {
+		t.Error("VerifyHMAC() should fail with a key derived for another purpose")
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() error = %v", err)
+	}
+	other, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() error = %v", err)
+	}
+
+	id, err := KeyID(keyPair.PublicKey)
+	if err != nil {
+		t.Fatalf("KeyID() error = %v", err)
+	}
+	if len(id) != 16 {
+		t.Errorf("KeyID() = %q, want 16 hex characters", id)
+	}
+
+	again, _ := KeyID(keyPair.PublicKey)
+	if again != id {
+		t.Errorf("KeyID() not stable: %q != %q", again, id)
+	}
+	otherID, _ := KeyID(other.PublicKey)
+	if otherID == id {
+		t.Error("KeyID() should differ between keys")
+	}
+}
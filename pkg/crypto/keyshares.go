@@ -0,0 +1,72 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// KeyShare 密钥的一份拆分，Data 是与 Mask 异或后的数据
+// 单独的 Data 或 Mask 都是随机数据，只有全部份额组合后才能得到密钥
+type KeyShare struct {
+	Data []byte `json:"data"`
+	Mask []byte `json:"mask"`
+}
+
+// SplitKey 将密钥拆分为 n 份，每份再用随机掩码遮盖
+// 每次调用的结果都不同，适合在每次构建时重新生成
+func SplitKey(key []byte, n int) ([]KeyShare, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key is empty")
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("number of shares must be at least 1, got %d", n)
+	}
+
+	shares := make([]KeyShare, n)
+	last := append([]byte(nil), key...)
+	for i := range shares {
+		var share []byte
+		if i == n-1 {
+			share = last
+		} else {
+			share = make([]byte, len(key))
+			if _, err := rand.Read(share); err != nil {
+				return nil, fmt.Errorf("failed to generate key share: %v", err)
+			}
+			xorInto(last, share)
+		}
+
+		mask := make([]byte, len(key))
+		if _, err := rand.Read(mask); err != nil {
+			return nil, fmt.Errorf("failed to generate key mask: %v", err)
+		}
+		xorInto(share, mask)
+		shares[i] = KeyShare{Data: share, Mask: mask}
+	}
+
+	return shares, nil
+}
+
+// CombineKey 组合全部份额还原密钥
+func CombineKey(shares ...KeyShare) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no key shares")
+	}
+
+	key := make([]byte, len(shares[0].Data))
+	for i, share := range shares {
+		if len(share.Data) != len(key) || len(share.Mask) != len(key) {
+			return nil, fmt.Errorf("key share %d has length %d/%d, want %d", i, len(share.Data), len(share.Mask), len(key))
+		}
+		xorInto(key, share.Data)
+		xorInto(key, share.Mask)
+	}
+	return key, nil
+}
+
+// xorInto 将 src 异或到 dst
+func xorInto(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitCombineKey(t *testing.T) {
+	key, err := GenerateAESKey()
+	if err != nil {
+		t.Fatalf("GenerateAESKey() error = %v", err)
+	}
+
+	for _, n := range []int{1, 2, 5} {
+		shares, err := SplitKey(key, n)
+		if err != nil {
+			t.Fatalf("SplitKey(%d) error = %v", n, err)
+		}
+		if len(shares) != n {
+			t.Fatalf("SplitKey(%d) returned %d shares", n, len(shares))
+		}
+		for i, share := range shares {
+			if bytes.Equal(share.Data, key) || bytes.Equal(share.Mask, key) {
+				t.Errorf("SplitKey(%d) share %d contains the plain key", n, i)
+			}
+		}
+
+		combined, err := CombineKey(shares...)
+		if err != nil {
+			t.Fatalf("CombineKey() error = %v", err)
+		}
+		if !bytes.Equal(combined, key) {
+			t.Errorf("CombineKey() with %d shares did not restore the key", n)
+		}
+
+		if n > 1 {
+			partial, _ := CombineKey(shares[1:]...)
+			if bytes.Equal(partial, key) {
+				t.Errorf("CombineKey() with %d of %d shares restored the key", n-1, n)
+			}
+		}
+	}
+
+	// 每次拆分的结果都不同
+	a, _ := SplitKey(key, 2)
+	b, _ := SplitKey(key, 2)
+	if bytes.Equal(a[0].Data, b[0].Data) {
+		t.Error("SplitKey() should produce different shares on each call")
+	}
+}
+
+func TestSplitCombineKeyErrors(t *testing.T) {
+	if _, err := SplitKey(nil, 2); err == nil {
+		t.Error("SplitKey() with empty key should fail")
+	}
+	if _, err := SplitKey([]byte("key"), 0); err == nil {
+		t.Error("SplitKey() with 0 shares should fail")
+	}
+	if _, err := CombineKey(); err == nil {
+		t.Error("CombineKey() without shares should fail")
+	}
+
+	shares, _ := SplitKey([]byte("key"), 2)
+	shares[1].Mask = shares[1].Mask[:1]
+	if _, err := CombineKey(shares...); err == nil {
+		t.Error("CombineKey() with mismatched lengths should fail")
+	}
+}
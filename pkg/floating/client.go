@@ -0,0 +1,301 @@
+package floating
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// Client 产品端使用的浮动许可证客户端
+type Client struct {
+	BaseURL    string       // 许可证服务器地址，如 http://license-server:8090
+	HTTPClient *http.Client // 为 nil 时使用默认客户端（30秒超时）
+	ClientID   string       // 客户端标识，为空时使用主机名和进程号
+	User       string       // 用户名，仅用于服务器状态展示
+
+	// Verifier 不为 nil 时，检出席位前先从服务器获取许可证并在本地验证（包括产品范围、吊销列表等），
+	// 并且只接受由租约私钥签名的租约（服务器需配置 WithLeaseKey）
+	// 浮动许可证通常不绑定机器；绑定到服务器机器的许可证在客户端验证会失败
+	Verifier *license.Verifier
+
+	// LeaseKey 验证租约签名的公钥，为 nil 时使用许可证中的 LeaseKey
+	LeaseKey *rsa.PublicKey
+}
+
+// NewClient 创建浮动许可证客户端
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Seat 已检出的席位
+type Seat struct {
+	client *Client
+
+	mu    sync.Mutex
+	lease *Lease
+
+	// Result 本地验证结果，Client.Verifier 为 nil 时为 nil
+	Result *license.VerificationResult
+}
+
+// Checkout 检出一个席位
+// 检出后需要定期续约（见 Seat.KeepAlive），使用结束后调用 Seat.Release 归还席位
+func (c *Client) Checkout(ctx context.Context) (*Seat, error) {
+	seat := &Seat{client: c}
+
+	if c.Verifier != nil {
+		data, err := c.do(ctx, http.MethodGet, "/v1/license", nil)
+		if err != nil {
+			return nil, err
+		}
+		result, err := c.Verifier.Verify(data)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Valid {
+			return nil, fmt.Errorf("%w: %v", ErrLicenseInvalid, result.Err)
+		}
+		seat.Result = result
+	}
+
+	lease, err := c.checkout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if lease, err = seat.verifyLease(lease); err != nil {
+		return nil, err
+	}
+	seat.lease = lease
+	return seat, nil
+}
+
+// Status 查询服务器状态
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	data, err := c.do(ctx, http.MethodGet, "/v1/status", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse status: %v", err)
+	}
+	return &status, nil
+}
+
+// Lease 返回当前租约
+func (s *Seat) Lease() Lease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.lease
+}
+
+// Renew 续约一次
+// 租约已被服务器回收（例如客户端长时间断网）时尝试重新检出，席位已满时返回 ErrNoSeatsAvailable
+func (s *Seat) Renew(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lease, err := s.client.leaseRequest(ctx, http.MethodPost, "/v1/leases/"+s.lease.ID+"/renew", nil)
+	if errors.Is(err, ErrLeaseNotFound) {
+		lease, err = s.client.checkout(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if lease, err = s.verifyLease(lease); err != nil {
+		return err
+	}
+	s.lease = lease
+	return nil
+}
+
+// verifyLease 客户端配置了 Verifier 时，校验租约签名，并检查租约属于本客户端和已验证的许可证
+// 返回签名中的租约，服务器未签名或没有可用的租约公钥时返回 ErrInvalidLease
+func (s *Seat) verifyLease(lease *Lease) (*Lease, error) {
+	if s.client.Verifier == nil {
+		return lease, nil
+	}
+	if len(lease.Signed) == 0 {
+		return nil, fmt.Errorf("%w: lease is not signed", ErrInvalidLease)
+	}
+
+	publicKey, err := s.leaseKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var signed Lease
+	if err := license.OpenDocument(lease.Signed, DocumentTypeLease, publicKey, &signed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLease, err)
+	}
+	if clientID := s.client.clientID(); signed.ClientID != clientID {
+		return nil, fmt.Errorf("%w: lease was issued to %q, not %q", ErrInvalidLease, signed.ClientID, clientID)
+	}
+	if signed.LicenseID != s.Result.License.ID {
+		return nil, fmt.Errorf("%w: lease was issued for license %s, not %s", ErrInvalidLease, signed.LicenseID, s.Result.License.ID)
+	}
+
+	signed.Signed = lease.Signed
+	return &signed, nil
+}
+
+// leaseKey 返回验证租约签名的公钥
+func (s *Seat) leaseKey() (*rsa.PublicKey, error) {
+	if s.client.LeaseKey != nil {
+		return s.client.LeaseKey, nil
+	}
+	if s.Result.License.LeaseKey == "" {
+		return nil, fmt.Errorf("%w: license does not specify a lease key", ErrInvalidLease)
+	}
+	publicKey, err := crypto.LoadPublicKeyFromPEM([]byte(s.Result.License.LeaseKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid lease key in license: %v", ErrInvalidLease, err)
+	}
+	return publicKey, nil
+}
+
+// KeepAlive 每隔租约有效期的三分之一续约一次，直到 ctx 取消或续约失败
+// 通常在单独的 goroutine 中运行；返回 ctx.Err() 或续约错误
+func (s *Seat) KeepAlive(ctx context.Context) error {
+	for {
+		interval := time.Duration(s.Lease().TTL) / 3
+		if interval <= 0 {
+			interval = DefaultLeaseTTL / 3
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if err := s.Renew(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// Release 归还席位
+func (s *Seat) Release(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.client.do(ctx, http.MethodDelete, "/v1/leases/"+s.lease.ID, nil)
+	if errors.Is(err, ErrLeaseNotFound) {
+		return nil
+	}
+	return err
+}
+
+// checkout 发送检出请求
+func (c *Client) checkout(ctx context.Context) (*Lease, error) {
+	return c.leaseRequest(ctx, http.MethodPost, "/v1/leases", &CheckoutRequest{ClientID: c.clientID(), User: c.User})
+}
+
+// clientID 返回客户端标识，未设置时使用主机名和进程号
+func (c *Client) clientID() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	}
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
+
+// leaseRequest 发送请求并解析返回的租约
+func (c *Client) leaseRequest(ctx context.Context, method, path string, body interface{}) (*Lease, error) {
+	data, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var lease Lease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return nil, fmt.Errorf("failed to parse lease: %v", err)
+	}
+	return &lease, nil
+}
+
+// do 发送请求，返回响应内容
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	url := strings.TrimRight(c.BaseURL, "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact license server: %v", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if httpResp.StatusCode >= 300 {
+		return nil, responseError(httpResp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// responseError 将错误响应转换为对应的错误类型
+func responseError(status int, data []byte) error {
+	var errResp errorResponse
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+		message = errResp.Error
+	}
+
+	var sentinel error
+	switch status {
+	case http.StatusBadRequest:
+		sentinel = ErrInvalidRequest
+	case http.StatusNotFound:
+		sentinel = ErrLeaseNotFound
+	case http.StatusConflict:
+		sentinel = ErrNoSeatsAvailable
+	case http.StatusServiceUnavailable:
+		sentinel = ErrLicenseInvalid
+	default:
+		return fmt.Errorf("%w (HTTP %d): %s", ErrRequestRejected, status, message)
+	}
+
+	if message == sentinel.Error() {
+		return sentinel
+	}
+	return fmt.Errorf("%w: %s", sentinel, strings.TrimPrefix(message, sentinel.Error()+": "))
+}
@@ -0,0 +1,241 @@
+package floating_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/floating"
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+)
+
+// newLeaseKey 生成租约签名密钥对
+func newLeaseKey(t *testing.T) *crypto.KeyPair {
+	t.Helper()
+
+	pair, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() error = %v", err)
+	}
+	return pair
+}
+
+// newServer 创建浮动许可证服务器，leaseKey 不为 nil 时写入许可证并用于签名租约
+func newServer(t *testing.T, maxUsers int, clock license.Clock, leaseKey *crypto.KeyPair) (*floating.Server, *license.Verifier) {
+	t.Helper()
+
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	options := &license.GenerateOptions{ProductName: "Editor", MaxUsers: maxUsers}
+	if leaseKey != nil {
+		leaseKeyPEM, _ := leaseKey.PublicKeyToPEM()
+		options.LeaseKey = string(leaseKeyPEM)
+	}
+	lic, err := generator.Generate(options)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	data, err := generator.Encode(lic)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(), license.WithProduct("Editor", ""))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	opts := []floating.ServerOption{floating.WithLeaseTTL(time.Minute), floating.WithClock(clock)}
+	if leaseKey != nil {
+		opts = append(opts, floating.WithLeaseKey(leaseKey.PrivateKey))
+	}
+	server, err := floating.NewServer(verifier, data, opts...)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return server, verifier
+}
+
+func TestSeatLimit(t *testing.T) {
+	clock := licensetest.NewClock(time.Now())
+	server, _ := newServer(t, 2, clock, nil)
+
+	first, err := server.Checkout(&floating.CheckoutRequest{ClientID: "a"})
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	if _, err := server.Checkout(&floating.CheckoutRequest{ClientID: "b"}); err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+
+	// 带租约ID重复检出复用租约
+	again, err := server.Checkout(&floating.CheckoutRequest{ClientID: "a", LeaseID: first.ID})
+	if err != nil || again.ID != first.ID {
+		t.Errorf("Checkout() same client = %v, %v, want lease %s", again, err, first.ID)
+	}
+
+	// 只凭公开的客户端标识不能取得其他客户端的租约ID
+	if replayed, err := server.Checkout(&floating.CheckoutRequest{ClientID: "a"}); err == nil {
+		t.Errorf("Checkout() replayed client ID returned lease %s", replayed.ID)
+	} else if !errors.Is(err, floating.ErrNoSeatsAvailable) {
+		t.Errorf("Checkout() replayed client ID error = %v, want %v", err, floating.ErrNoSeatsAvailable)
+	}
+
+	if _, err := server.Checkout(&floating.CheckoutRequest{ClientID: "c"}); !errors.Is(err, floating.ErrNoSeatsAvailable) {
+		t.Errorf("Checkout() error = %v, want %v", err, floating.ErrNoSeatsAvailable)
+	}
+
+	// a 续约，b 过期被回收
+	clock.Advance(40 * time.Second)
+	if _, err := server.Renew(first.ID); err != nil {
+		t.Fatalf("Renew() error = %v", err)
+	}
+	clock.Advance(40 * time.Second)
+
+	if _, err := server.Checkout(&floating.CheckoutRequest{ClientID: "c"}); err != nil {
+		t.Errorf("Checkout() after expiry error = %v", err)
+	}
+
+	if err := server.Release(first.ID); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := server.Renew(first.ID); !errors.Is(err, floating.ErrLeaseNotFound) {
+		t.Errorf("Renew() released lease error = %v, want %v", err, floating.ErrLeaseNotFound)
+	}
+
+	status, err := server.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.MaxUsers != 2 || status.InUse != 1 {
+		t.Errorf("Status() = %d/%d, want 1/2", status.InUse, status.MaxUsers)
+	}
+	// 租约ID是续约和归还的凭据，状态中不能包含
+	for _, lease := range status.Leases {
+		if lease.ID != "" {
+			t.Errorf("Status() lease ID = %q, want empty", lease.ID)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	clock := licensetest.NewClock(time.Now())
+	server, verifier := newServer(t, 1, clock, newLeaseKey(t))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client := floating.NewClient(httpServer.URL)
+	client.ClientID = "workstation-1"
+	client.Verifier = verifier
+
+	seat, err := client.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	if seat.Result == nil || !seat.Result.Valid {
+		t.Fatalf("Checkout() result = %+v, want valid", seat.Result)
+	}
+
+	other := floating.NewClient(httpServer.URL)
+	other.ClientID = "workstation-2"
+	if _, err := other.Checkout(context.Background()); !errors.Is(err, floating.ErrNoSeatsAvailable) {
+		t.Errorf("Checkout() error = %v, want %v", err, floating.ErrNoSeatsAvailable)
+	}
+
+	// 租约被回收后续约会重新检出
+	clock.Advance(2 * time.Minute)
+	if err := seat.Renew(context.Background()); err != nil {
+		t.Fatalf("Renew() error = %v", err)
+	}
+
+	if err := seat.Release(context.Background()); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := other.Checkout(context.Background()); err != nil {
+		t.Errorf("Checkout() after release error = %v", err)
+	}
+}
+
+func TestClientRejectsUnsignedLease(t *testing.T) {
+	clock := licensetest.NewClock(time.Now())
+	server, verifier := newServer(t, 1, clock, nil)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client := floating.NewClient(httpServer.URL)
+	client.ClientID = "workstation-1"
+	client.Verifier = verifier
+
+	if _, err := client.Checkout(context.Background()); !errors.Is(err, floating.ErrInvalidLease) {
+		t.Errorf("Checkout() error = %v, want %v", err, floating.ErrInvalidLease)
+	}
+}
+
+func TestClientRejectsForeignLeaseKey(t *testing.T) {
+	clock := licensetest.NewClock(time.Now())
+	server, verifier := newServer(t, 1, clock, newLeaseKey(t))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// 客户端配置的租约公钥优先于许可证中的租约公钥
+	client := floating.NewClient(httpServer.URL)
+	client.ClientID = "workstation-1"
+	client.Verifier = verifier
+	client.LeaseKey = newLeaseKey(t).PublicKey
+
+	if _, err := client.Checkout(context.Background()); !errors.Is(err, floating.ErrInvalidLease) {
+		t.Errorf("Checkout() error = %v, want %v", err, floating.ErrInvalidLease)
+	}
+}
+
+func TestServerLeaseKeyMismatch(t *testing.T) {
+	generator, _ := license.NewGenerator()
+	leaseKeyPEM, _ := newLeaseKey(t).PublicKeyToPEM()
+	lic, _ := generator.Generate(&license.GenerateOptions{MaxUsers: 1, LeaseKey: string(leaseKeyPEM)})
+	data, _ := generator.Encode(lic)
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, _ := license.NewVerifier(publicKeyPEM, generator.GetAESKey())
+
+	if _, err := floating.NewServer(verifier, data); err == nil {
+		t.Error("NewServer() should require the lease private key named by the license")
+	}
+	if _, err := floating.NewServer(verifier, data, floating.WithLeaseKey(newLeaseKey(t).PrivateKey)); err == nil {
+		t.Error("NewServer() should reject a lease private key that does not match the license")
+	}
+}
+
+func TestServerRequiresMaxUsers(t *testing.T) {
+	generator, _ := license.NewGenerator()
+	lic, _ := generator.Generate(&license.GenerateOptions{})
+	data, _ := generator.Encode(lic)
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, _ := license.NewVerifier(publicKeyPEM, generator.GetAESKey())
+
+	if _, err := floating.NewServer(verifier, data); err == nil {
+		t.Error("NewServer() should reject a license without max_users")
+	}
+}
+
+func TestServerIgnoresIntegrity(t *testing.T) {
+	generator, _ := license.NewGenerator()
+	lic, _ := generator.Generate(&license.GenerateOptions{MaxUsers: 1, RequireIntegrity: true})
+	data, _ := generator.Encode(lic)
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, _ := license.NewVerifier(publicKeyPEM, generator.GetAESKey())
+
+	// 完整性只能在客户端检查，服务器没有完整性清单
+	server, err := floating.NewServer(verifier, data)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if _, err := server.Checkout(&floating.CheckoutRequest{ClientID: "a"}); err != nil {
+		t.Errorf("Checkout() error = %v", err)
+	}
+}
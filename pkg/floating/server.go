@@ -0,0 +1,349 @@
+package floating
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// maxRequestSize 请求体的最大字节数
+const maxRequestSize = 16 << 10
+
+// Server 浮动许可证服务器
+// 每次检出席位时都会重新验证许可证，许可证过期或被吊销后不再发放新租约
+type Server struct {
+	mu          sync.Mutex
+	verifier    *license.Verifier
+	leaseKey    *rsa.PrivateKey
+	licenseData []byte
+	ttl         time.Duration
+	clock       license.Clock
+	leases      map[string]*Lease
+	mux         *http.ServeMux
+}
+
+// ServerOption 服务器选项
+type ServerOption func(*Server)
+
+// WithLeaseTTL 设置租约有效期
+func WithLeaseTTL(ttl time.Duration) ServerOption {
+	return func(s *Server) {
+		s.ttl = ttl
+	}
+}
+
+// WithClock 设置服务器计算租约过期使用的时钟
+func WithClock(clock license.Clock) ServerOption {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// WithLeaseKey 设置签名租约使用的私钥，客户端配置了 Verifier 时只接受签名的租约
+// 租约私钥由部署服务器的客户生成并保管，对应的公钥写入许可证的 LeaseKey（或配置到客户端），不需要签发私钥
+func WithLeaseKey(privateKey *rsa.PrivateKey) ServerOption {
+	return func(s *Server) {
+		s.leaseKey = privateKey
+	}
+}
+
+// NewServer 创建浮动许可证服务器
+// licenseData 必须能通过 verifier 的验证，且许可证的 MaxUsers 大于 0
+func NewServer(verifier *license.Verifier, licenseData []byte, opts ...ServerOption) (*Server, error) {
+	s := &Server{
+		verifier:    verifier,
+		licenseData: licenseData,
+		ttl:         DefaultLeaseTTL,
+		clock:       license.ClockFunc(time.Now),
+		leases:      make(map[string]*Lease),
+		mux:         http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.ttl <= 0 {
+		return nil, fmt.Errorf("lease TTL must be positive")
+	}
+
+	lic, err := s.license()
+	if err != nil {
+		return nil, err
+	}
+	if lic.MaxUsers <= 0 {
+		return nil, fmt.Errorf("license does not limit concurrent users (max_users is %d)", lic.MaxUsers)
+	}
+	if err := s.checkLeaseKey(lic); err != nil {
+		return nil, err
+	}
+
+	s.mux.HandleFunc("POST /v1/leases", s.handleCheckout)
+	s.mux.HandleFunc("POST /v1/leases/{id}/renew", s.handleRenew)
+	s.mux.HandleFunc("DELETE /v1/leases/{id}", s.handleRelease)
+	s.mux.HandleFunc("GET /v1/status", s.handleStatus)
+	s.mux.HandleFunc("GET /v1/license", s.handleLicense)
+	return s, nil
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Checkout 检出一个席位
+// 请求带有该客户端已持有的租约ID时续约并返回该租约，不占用新的席位；
+// 客户端标识是公开的（见 Status），不带租约ID的请求总是检出新的租约，不会返回其他租约的ID
+func (s *Server) Checkout(req *CheckoutRequest) (*Lease, error) {
+	if req.ClientID == "" {
+		return nil, fmt.Errorf("%w: client ID is required", ErrInvalidRequest)
+	}
+
+	lic, err := s.license()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.reap(now)
+
+	if lease, ok := s.leases[req.LeaseID]; ok && lease.ClientID == req.ClientID {
+		lease.User = req.User
+		lease.ExpiresAt = now.Add(s.ttl)
+		return s.sign(lease)
+	}
+
+	if len(s.leases) >= lic.MaxUsers {
+		return nil, fmt.Errorf("%w: all %d seats are in use", ErrNoSeatsAvailable, lic.MaxUsers)
+	}
+
+	id, err := newLeaseID()
+	if err != nil {
+		return nil, err
+	}
+
+	lease := &Lease{
+		ID:        id,
+		ClientID:  req.ClientID,
+		User:      req.User,
+		LicenseID: lic.ID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.ttl),
+		TTL:       Duration(s.ttl),
+	}
+	s.leases[id] = lease
+
+	return s.sign(lease)
+}
+
+// Renew 续约，租约已过期被回收时返回 ErrLeaseNotFound
+func (s *Server) Renew(id string) (*Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.reap(now)
+
+	lease, ok := s.leases[id]
+	if !ok {
+		return nil, ErrLeaseNotFound
+	}
+	lease.ExpiresAt = now.Add(s.ttl)
+
+	return s.sign(lease)
+}
+
+// Release 归还席位
+func (s *Server) Release(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reap(s.clock.Now())
+
+	if _, ok := s.leases[id]; !ok {
+		return ErrLeaseNotFound
+	}
+	delete(s.leases, id)
+	return nil
+}
+
+// Status 返回服务器当前状态，租约中不包含租约ID
+func (s *Server) Status() (*Status, error) {
+	lic, err := s.license()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reap(s.clock.Now())
+
+	status := &Status{
+		LicenseID:   lic.ID,
+		ProductName: lic.ProductName,
+		ExpiresAt:   lic.ExpiresAt,
+		MaxUsers:    lic.MaxUsers,
+		InUse:       len(s.leases),
+		Leases:      make([]Lease, 0, len(s.leases)),
+	}
+	for _, lease := range s.leases {
+		copied := *lease
+		copied.ID = ""
+		status.Leases = append(status.Leases, copied)
+	}
+	sort.Slice(status.Leases, func(i, j int) bool {
+		return status.Leases[i].IssuedAt.Before(status.Leases[j].IssuedAt)
+	})
+	return status, nil
+}
+
+// checkLeaseKey 检查租约私钥与许可证中的租约公钥是否对应
+func (s *Server) checkLeaseKey(lic *license.License) error {
+	if lic.LeaseKey == "" {
+		return nil
+	}
+	if s.leaseKey == nil {
+		return fmt.Errorf("license specifies a lease key but no lease private key is configured")
+	}
+	publicKey, err := crypto.LoadPublicKeyFromPEM([]byte(lic.LeaseKey))
+	if err != nil {
+		return fmt.Errorf("invalid lease key in license: %v", err)
+	}
+	if !publicKey.Equal(&s.leaseKey.PublicKey) {
+		return fmt.Errorf("lease private key does not match the lease key in the license")
+	}
+	return nil
+}
+
+// sign 返回租约的副本，配置了租约私钥时附带签名的租约
+func (s *Server) sign(lease *Lease) (*Lease, error) {
+	copied := *lease
+	if s.leaseKey == nil {
+		return &copied, nil
+	}
+
+	signed, err := license.SignDocument(DocumentTypeLease, &copied, s.leaseKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign lease: %v", err)
+	}
+	copied.Signed = signed
+	return &copied, nil
+}
+
+// reap 回收已过期的租约，调用方需持有锁
+func (s *Server) reap(now time.Time) {
+	for id, lease := range s.leases {
+		if !now.Before(lease.ExpiresAt) {
+			delete(s.leases, id)
+		}
+	}
+}
+
+// license 验证服务器持有的许可证
+// 可执行文件完整性只能在客户端检查，服务器不检查
+func (s *Server) license() (*license.License, error) {
+	result, err := s.verifier.Verify(s.licenseData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLicenseInvalid, err)
+	}
+	if result.License == nil {
+		return nil, fmt.Errorf("%w: %s", ErrLicenseInvalid, result.Error)
+	}
+	for _, check := range result.FailedChecks() {
+		if check.Name != license.CheckIntegrity {
+			return nil, fmt.Errorf("%w: %s", ErrLicenseInvalid, check.Message)
+		}
+	}
+	return result.License, nil
+}
+
+// handleCheckout 处理 POST /v1/leases
+func (s *Server) handleCheckout(w http.ResponseWriter, r *http.Request) {
+	var req CheckoutRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	lease, err := s.Checkout(&req)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, lease)
+}
+
+// handleRenew 处理 POST /v1/leases/{id}/renew
+func (s *Server) handleRenew(w http.ResponseWriter, r *http.Request) {
+	lease, err := s.Renew(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, lease)
+}
+
+// handleRelease 处理 DELETE /v1/leases/{id}
+func (s *Server) handleRelease(w http.ResponseWriter, r *http.Request) {
+	if err := s.Release(r.PathValue("id")); err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleStatus 处理 GET /v1/status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Status()
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleLicense 处理 GET /v1/license，返回许可证文件，供客户端自行验证
+func (s *Server) handleLicense(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.licenseData)
+}
+
+// statusFor 返回错误对应的HTTP状态码
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrLeaseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNoSeatsAvailable):
+		return http.StatusConflict
+	case errors.Is(err, ErrLicenseInvalid):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出错误响应
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &errorResponse{Error: message})
+}
@@ -0,0 +1,93 @@
+// Package floating 实现浮动（并发）许可证：
+// 许可证服务器持有一份许可证，按 MaxUsers 限制同时在线的客户端数量，
+// 客户端检出有时限的席位租约，通过心跳续约，过期未续约的租约会被服务器回收。
+package floating
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultLeaseTTL 默认的租约有效期
+const DefaultLeaseTTL = 5 * time.Minute
+
+// DocumentTypeLease 签名的席位租约的文档类型
+const DocumentTypeLease = "floating-lease"
+
+// 浮动许可证的错误类型
+var (
+	ErrNoSeatsAvailable = errors.New("no seats available")
+	ErrLeaseNotFound    = errors.New("lease not found or expired")
+	ErrInvalidRequest   = errors.New("invalid lease request")
+	ErrLicenseInvalid   = errors.New("license server has no valid license")
+	ErrRequestRejected  = errors.New("lease request rejected by server")
+	ErrInvalidLease     = errors.New("invalid lease")
+)
+
+// Lease 席位租约
+// 租约ID是续约和归还租约的唯一凭据，服务器状态中不包含租约ID
+type Lease struct {
+	ID        string    `json:"id,omitempty"`     // 租约ID
+	ClientID  string    `json:"client_id"`        // 客户端标识
+	User      string    `json:"user,omitempty"`   // 用户名
+	LicenseID string    `json:"license_id"`       // 许可证ID
+	IssuedAt  time.Time `json:"issued_at"`        // 检出时间
+	ExpiresAt time.Time `json:"expires_at"`       // 过期时间，需在此之前续约
+	TTL       Duration  `json:"ttl"`              // 租约有效期
+	Signed    []byte    `json:"signed,omitempty"` // 租约私钥签名的租约（不含本字段），服务器配置了 WithLeaseKey 时返回
+}
+
+// CheckoutRequest 检出席位请求
+type CheckoutRequest struct {
+	ClientID string `json:"client_id"`          // 客户端标识
+	User     string `json:"user,omitempty"`     // 用户名
+	LeaseID  string `json:"lease_id,omitempty"` // 已持有的租约ID，与客户端标识一致时复用该租约
+}
+
+// Status 服务器状态
+type Status struct {
+	LicenseID   string    `json:"license_id"`   // 许可证ID
+	ProductName string    `json:"product_name"` // 产品名称
+	ExpiresAt   time.Time `json:"expires_at"`   // 许可证过期时间
+	MaxUsers    int       `json:"max_users"`    // 最大并发用户数
+	InUse       int       `json:"in_use"`       // 已检出的席位数
+	Leases      []Lease   `json:"leases"`       // 当前租约，不包含租约ID
+}
+
+// Duration 以字符串（如 "5m0s"）序列化的时长
+type Duration time.Duration
+
+// MarshalJSON 实现 json.Marshaler 接口
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Duration(d).String() + `"`), nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	parsed, err := time.ParseDuration(string(data[1 : len(data)-1]))
+	if err != nil {
+		return fmt.Errorf("invalid duration: %v", err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newLeaseID 生成随机租约ID
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lease ID: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
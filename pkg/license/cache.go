@@ -0,0 +1,206 @@
+package license
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// DefaultCacheTTL 默认的缓存有效期
+const DefaultCacheTTL = time.Minute
+
+// maxCacheEntries 验证结果缓存的最大条目数
+const maxCacheEntries = 64
+
+// CachedMachineInfo 缓存机器信息的提供者
+// machine.GetAllInfo 每次都会启动外部进程（cat、powershell 等），缓存后同一进程内只在过期时重新获取
+type CachedMachineInfo struct {
+	provider MachineInfoProvider
+	ttl      time.Duration
+	clock    Clock
+
+	mu        sync.Mutex
+	info      *machine.MachineInfo
+	fetchedAt time.Time
+}
+
+// NewCachedMachineInfo 创建缓存机器信息的提供者，provider 为 nil 时使用本机信息
+// 获取失败的结果不会被缓存
+func NewCachedMachineInfo(provider MachineInfoProvider, ttl time.Duration) *CachedMachineInfo {
+	if provider == nil {
+		provider = systemMachineInfo{}
+	}
+	return &CachedMachineInfo{provider: provider, ttl: ttl, clock: ClockFunc(time.Now)}
+}
+
+// SetClock 设置计算缓存有效期使用的时钟
+func (c *CachedMachineInfo) SetClock(clock Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
+
+// GetAllInfo 返回缓存的机器信息，缓存过期时重新获取
+func (c *CachedMachineInfo) GetAllInfo() (*machine.MachineInfo, error) {
+	return c.GetAllInfoContext(context.Background())
+}
+
+// GetAllInfoContext 返回缓存的机器信息，缓存过期时在 ctx 的限制内重新获取
+func (c *CachedMachineInfo) GetAllInfoContext(ctx context.Context) (*machine.MachineInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.info != nil && c.clock.Now().Sub(c.fetchedAt) < c.ttl {
+		info := *c.info
+		return &info, nil
+	}
+
+	info, err := getMachineInfo(ctx, c.provider)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := *info
+	c.info = &cached
+	c.fetchedAt = c.clock.Now()
+	return info, nil
+}
+
+// Invalidate 清除缓存，下次调用时重新获取
+func (c *CachedMachineInfo) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.info = nil
+}
+
+// WithMachineInfoCache 缓存验证器使用的机器信息，应放在 WithMachineInfoProvider 之后
+// 缓存有效期按验证器的时钟计算，与 CachingVerifier 一致
+func WithMachineInfoCache(ttl time.Duration) Option {
+	return func(v *Verifier) {
+		cached := NewCachedMachineInfo(v.machineInfo, ttl)
+		cached.SetClock(ClockFunc(func() time.Time { return v.clock.Now() }))
+		v.machineInfo = cached
+	}
+}
+
+// CachingVerifier 缓存验证结果的验证器
+// 以许可证内容的 SHA-256 为键，在 TTL 内直接返回上次的结果，跳过签名验证、解密和机器信息获取。
+// 有效结果的缓存不会超过许可证（或产品授权、签到租约）的过期时间。
+// 返回的结果在缓存中共享，调用方不应修改。
+// 命中缓存时同样记录日志并通知底层验证器的观察者（例如 metrics.Collector），结果是缓存的那一次验证。
+type CachingVerifier struct {
+	verifier *Verifier
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*cacheEntry
+}
+
+// cacheEntry 缓存的验证结果
+type cacheEntry struct {
+	result    *VerificationResult
+	expiresAt time.Time
+}
+
+// NewCachingVerifier 创建缓存验证结果的验证器
+func NewCachingVerifier(verifier *Verifier, ttl time.Duration) *CachingVerifier {
+	return &CachingVerifier{
+		verifier: verifier,
+		ttl:      ttl,
+		entries:  make(map[[sha256.Size]byte]*cacheEntry),
+	}
+}
+
+// Verifier 返回底层的验证器
+func (c *CachingVerifier) Verifier() *Verifier {
+	return c.verifier
+}
+
+// Verify 验证许可证数据，命中缓存时直接返回缓存的结果
+func (c *CachingVerifier) Verify(fileData []byte) (*VerificationResult, error) {
+	return c.VerifyContext(context.Background(), fileData)
+}
+
+// VerifyContext 验证许可证数据，未命中缓存时在 ctx 的限制内验证，超时或取消的结果不会被缓存
+func (c *CachingVerifier) VerifyContext(ctx context.Context, fileData []byte) (*VerificationResult, error) {
+	key := sha256.Sum256(fileData)
+	now := c.verifier.clock.Now()
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expiresAt) {
+		c.mu.Unlock()
+		// 命中缓存时返回带 Cached 标记的副本，观察者和日志可以与实际验证区分
+		cached := *entry.result
+		cached.Cached = true
+		c.verifier.report(ctx, &cached)
+		return &cached, nil
+	}
+	c.mu.Unlock()
+
+	result, err := c.verifier.VerifyContext(ctx, fileData)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return result, nil
+	}
+
+	expiresAt := now.Add(c.ttl)
+	if result.Valid {
+		if untilExpiry := now.Add(time.Duration(result.ExpiresIn) * time.Second); untilExpiry.Before(expiresAt) {
+			expiresAt = untilExpiry
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCacheEntries {
+		c.evict(now)
+	}
+	c.entries[key] = &cacheEntry{result: result, expiresAt: expiresAt}
+	return result, nil
+}
+
+// VerifyFile 读取并验证许可证文件，文件内容未变化时命中缓存
+func (c *CachingVerifier) VerifyFile(filePath string) (*VerificationResult, error) {
+	return c.VerifyFileContext(context.Background(), filePath)
+}
+
+// VerifyFileContext 读取并在 ctx 的限制内验证许可证文件，文件内容未变化时命中缓存
+func (c *CachingVerifier) VerifyFileContext(ctx context.Context, filePath string) (*VerificationResult, error) {
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		result := &VerificationResult{
+			VerifiedAt: c.verifier.clock.Now(),
+		}
+		result.fail(CheckFormat, "", "", ErrReadLicense, fmt.Sprintf("failed to read license file: %v", err))
+		c.verifier.report(ctx, result)
+		return result, nil
+	}
+	return c.VerifyContext(ctx, fileData)
+}
+
+// Invalidate 清除所有缓存的结果，例如在运行期间加载了新的吊销列表之后
+func (c *CachingVerifier) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[[sha256.Size]byte]*cacheEntry)
+}
+
+// evict 删除过期的条目，仍然已满时清空缓存，调用方需持有锁
+func (c *CachingVerifier) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) >= maxCacheEntries {
+		c.entries = make(map[[sha256.Size]byte]*cacheEntry)
+	}
+}
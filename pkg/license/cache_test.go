@@ -0,0 +1,145 @@
+package license_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+)
+
+func TestCachingVerifier(t *testing.T) {
+	var observed []*license.VerificationResult
+	generator, verifier, clock, info := newTestSetup(t, license.WithObserver(func(result *license.VerificationResult) {
+		observed = append(observed, result)
+	}))
+	data := issue(t, generator, &license.GenerateOptions{MAC: testMAC, Duration: time.Hour})
+
+	cache := license.NewCachingVerifier(verifier, 10*time.Minute)
+	for i := 0; i < 3; i++ {
+		result, _ := cache.Verify(data)
+		if !result.Valid {
+			t.Fatalf("Verify() invalid: %s", result.Error)
+		}
+		if result.Cached != (i > 0) {
+			t.Errorf("Verify() #%d Cached = %v, want %v", i, result.Cached, i > 0)
+		}
+	}
+	if calls := info.Calls(); calls != 1 {
+		t.Errorf("machine info calls = %d, want 1", calls)
+	}
+	// 命中缓存的验证同样通知观察者，并带有 Cached 标记
+	if len(observed) != 3 || observed[0].Cached || !observed[1].Cached || !observed[2].Cached {
+		t.Errorf("observed = %d results, want 1 fresh and 2 cached", len(observed))
+	}
+
+	// TTL 过期后重新验证
+	clock.Advance(11 * time.Minute)
+	cache.Verify(data)
+	if calls := info.Calls(); calls != 2 {
+		t.Errorf("machine info calls after TTL = %d, want 2", calls)
+	}
+
+	// 缓存不会超过许可证的过期时间
+	clock.Advance(50 * time.Minute)
+	if result, _ := cache.Verify(data); !errors.Is(result.Err, license.ErrExpired) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrExpired)
+	}
+}
+
+func TestCachedMachineInfo(t *testing.T) {
+	info := licensetest.NewMachineInfo(testMAC, testUUID, testCPUID)
+	cached := license.NewCachedMachineInfo(info, time.Hour)
+
+	// 获取失败时不缓存
+	info.SetError(errors.New("unavailable"))
+	if _, err := cached.GetAllInfo(); err == nil {
+		t.Fatal("GetAllInfo() should return the provider error")
+	}
+	info.SetError(nil)
+
+	for i := 0; i < 3; i++ {
+		got, err := cached.GetAllInfo()
+		if err != nil || got.MAC != testMAC {
+			t.Fatalf("GetAllInfo() = %v, %v", got, err)
+		}
+	}
+	if calls := info.Calls(); calls != 2 {
+		t.Errorf("provider calls = %d, want 2", calls)
+	}
+
+	cached.Invalidate()
+	cached.GetAllInfo()
+	if calls := info.Calls(); calls != 3 {
+		t.Errorf("provider calls after Invalidate() = %d, want 3", calls)
+	}
+}
+
+func TestMachineInfoCacheUsesVerifierClock(t *testing.T) {
+	generator, verifier, clock, info := newTestSetup(t, license.WithMachineInfoCache(10*time.Minute))
+	data := issue(t, generator, &license.GenerateOptions{MAC: testMAC, Duration: time.Hour})
+
+	verifier.Verify(data)
+	verifier.Verify(data)
+	if calls := info.Calls(); calls != 1 {
+		t.Errorf("machine info calls = %d, want 1", calls)
+	}
+
+	// 缓存有效期按验证器的时钟计算，而不是系统时间
+	clock.Advance(11 * time.Minute)
+	verifier.Verify(data)
+	if calls := info.Calls(); calls != 2 {
+		t.Errorf("machine info calls after TTL = %d, want 2", calls)
+	}
+}
+
+// newBenchSetup 创建使用本机机器信息的验证器和一份不绑定机器的许可证
+func newBenchSetup(b *testing.B, opts ...license.Option) (*license.Verifier, []byte) {
+	b.Helper()
+
+	generator, err := license.NewGenerator()
+	if err != nil {
+		b.Fatalf("NewGenerator() error = %v", err)
+	}
+	lic, err := generator.Generate(&license.GenerateOptions{})
+	if err != nil {
+		b.Fatalf("Generate() error = %v", err)
+	}
+	data, err := generator.Encode(lic)
+	if err != nil {
+		b.Fatalf("Encode() error = %v", err)
+	}
+
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(), opts...)
+	if err != nil {
+		b.Fatalf("NewVerifier() error = %v", err)
+	}
+	return verifier, data
+}
+
+func BenchmarkVerify(b *testing.B) {
+	verifier, data := newBenchSetup(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		verifier.Verify(data)
+	}
+}
+
+func BenchmarkVerifyCachedMachineInfo(b *testing.B) {
+	verifier, data := newBenchSetup(b, license.WithMachineInfoCache(time.Hour))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		verifier.Verify(data)
+	}
+}
+
+func BenchmarkCachingVerifier(b *testing.B) {
+	verifier, data := newBenchSetup(b)
+	cache := license.NewCachingVerifier(verifier, time.Hour)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Verify(data)
+	}
+}
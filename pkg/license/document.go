@@ -0,0 +1,101 @@
+package license
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+)
+
+// DocumentFormatVersion 签名文档的格式版本
+const DocumentFormatVersion = "1.0"
+
+// SignedDocument 签名文档的文件结构，用于时间令牌等由签发私钥签名的附属文件
+// 与许可证文件不同，签名文档的内容不加密
+type SignedDocument struct {
+	Type      string `json:"type"`      // 文档类型
+	Data      string `json:"data"`      // 文档内容（Base64编码的JSON）
+	Signature string `json:"signature"` // 数字签名
+	Version   string `json:"version"`   // 文件格式版本
+}
+
+// signDocument 序列化并签名文档，签名同时覆盖文档类型，防止不同类型的文档被互相替换
+func signDocument(docType string, payload interface{}, privateKey *rsa.PrivateKey) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %v", docType, err)
+	}
+
+	signature, err := crypto.SignData(documentSigningInput(docType, data), privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %s: %v", docType, err)
+	}
+
+	doc := &SignedDocument{
+		Type:      docType,
+		Data:      crypto.EncodeBase64(data),
+		Signature: crypto.EncodeBase64(signature),
+		Version:   DocumentFormatVersion,
+	}
+
+	fileData, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %v", docType, err)
+	}
+
+	return fileData, nil
+}
+
+// openDocument 校验文档类型和签名，并将内容解析到 out
+func openDocument(fileData []byte, docType string, publicKey *rsa.PublicKey, out interface{}) error {
+	var doc SignedDocument
+	if err := json.Unmarshal(fileData, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", docType, err)
+	}
+
+	if doc.Version != DocumentFormatVersion {
+		return fmt.Errorf("unsupported %s format version: %s", docType, doc.Version)
+	}
+	if doc.Type != docType {
+		return fmt.Errorf("expected %s, got %q", docType, doc.Type)
+	}
+
+	data, err := crypto.DecodeBase64(doc.Data)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %v", docType, err)
+	}
+
+	signature, err := crypto.DecodeBase64(doc.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s signature: %v", docType, err)
+	}
+
+	if err := crypto.VerifySignature(documentSigningInput(docType, data), signature, publicKey); err != nil {
+		return fmt.Errorf("%s %v", docType, err)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", docType, err)
+	}
+
+	return nil
+}
+
+// SignDocument 使用指定私钥签名其他包定义的文档（例如浮动许可证服务器的席位租约），docType 应与本包的文档类型不同
+func SignDocument(docType string, payload interface{}, privateKey *rsa.PrivateKey) ([]byte, error) {
+	return signDocument(docType, payload, privateKey)
+}
+
+// OpenDocument 校验由 SignDocument 签名的文档，并将内容解析到 out
+func OpenDocument(data []byte, docType string, publicKey *rsa.PublicKey, out interface{}) error {
+	return openDocument(data, docType, publicKey, out)
+}
+
+// documentSigningInput 返回被签名的内容：文档类型 + 换行 + 文档内容
+func documentSigningInput(docType string, data []byte) []byte {
+	input := make([]byte, 0, len(docType)+1+len(data))
+	input = append(input, docType...)
+	input = append(input, '\n')
+	return append(input, data...)
+}
@@ -0,0 +1,52 @@
+package license
+
+import (
+	"errors"
+)
+
+// 验证失败的错误类型，可配合 errors.Is 判断 VerificationResult.Err
+var (
+	ErrReadLicense        = errors.New("failed to read license file")
+	ErrInvalidFormat      = errors.New("invalid license file format")
+	ErrUnsupportedVersion = errors.New("unsupported file format version")
+	ErrSignature          = errors.New("signature verification failed")
+	ErrDecrypt            = errors.New("failed to decrypt license data")
+	ErrNotYetValid        = errors.New("license is not yet valid")
+	ErrExpired            = errors.New("license has expired")
+	ErrProductMismatch    = errors.New("license is not valid for this product")
+	ErrMachineInfo        = errors.New("failed to get machine info")
+	ErrMachineMismatch    = errors.New("machine information does not match")
+	ErrClockRollback      = errors.New("system clock has been rolled back")
+	ErrTimeStore          = errors.New("time store is unavailable or has been tampered with")
+	ErrRevoked            = errors.New("license has been revoked")
+	ErrLeaseExpired       = errors.New("check-in lease is missing or expired")
+	ErrTimeout            = errors.New("verification timed out")
+	ErrIntegrity          = errors.New("executable integrity check failed")
+)
+
+// ErrStaleRevocationList 吊销列表的序号比已加载的列表更旧
+var ErrStaleRevocationList = errors.New("revocation list is older than the loaded one")
+
+// ErrSourceNotFound 来源中没有内容（文件不存在、环境变量未设置等），FirstOf 会继续尝试下一个来源
+var ErrSourceNotFound = errors.New("license source not found")
+
+// VerificationError 验证失败的详细错误
+// 通过 errors.As 获取失败的检查项，通过 errors.Is 与上面的错误类型比较
+type VerificationError struct {
+	Check   string // 失败的检查项名称
+	Err     error  // 错误类型
+	Message string // 详细错误信息
+}
+
+// Error 实现 error 接口
+func (e *VerificationError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Err.Error()
+}
+
+// Unwrap 返回错误类型
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
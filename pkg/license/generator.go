@@ -5,6 +5,7 @@ import (
 	"crypto/rsa"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 type Generator struct {
 	privateKey *rsa.PrivateKey
 	aesKey     []byte
+	logger     *slog.Logger
 }
 
 // NewGenerator 创建新的生成器
@@ -74,20 +76,79 @@ func (g *Generator) Generate(options *GenerateOptions) (*License, error) {
 	}
 
 	now := time.Now()
+	expiresAt := now.Add(options.Duration)
+
+	// 产品授权未设置过期时间时继承许可证的过期时间
+	var products []ProductGrant
+	for _, grant := range options.Products {
+		if grant.Name == "" {
+			return nil, fmt.Errorf("product grant name cannot be empty")
+		}
+		if grant.ExpiresAt.IsZero() {
+			grant.ExpiresAt = expiresAt
+		}
+		if grant.ExpiresAt.After(expiresAt) {
+			return nil, fmt.Errorf("product grant %q expires after the license", grant.Name)
+		}
+		if grant.MinVersion != "" && grant.MaxVersion != "" && compareVersions(grant.MinVersion, grant.MaxVersion) > 0 {
+			return nil, fmt.Errorf("product grant %q has an empty version range", grant.Name)
+		}
+		products = append(products, grant)
+	}
+
+	// 检查机器列表
+	for i, m := range options.Machines {
+		if m.MAC == "" && m.UUID == "" && m.CPUID == "" {
+			return nil, fmt.Errorf("machine #%d has no MAC, UUID or CPUID", i+1)
+		}
+	}
+	maxMachines := options.MaxMachines
+	if maxMachines == 0 {
+		maxMachines = len(options.Machines)
+	}
+	if len(options.Machines) > maxMachines {
+		return nil, fmt.Errorf("too many machines: %d (max %d)", len(options.Machines), maxMachines)
+	}
+
+	if options.MatchPolicy != nil {
+		if err := options.MatchPolicy.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid match policy: %v", err)
+		}
+	}
+
+	if options.CheckIn != nil {
+		if err := options.CheckIn.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid check-in policy: %v", err)
+		}
+	}
+
+	if options.LeaseKey != "" {
+		if _, err := crypto.LoadPublicKeyFromPEM([]byte(options.LeaseKey)); err != nil {
+			return nil, fmt.Errorf("invalid lease key: %v", err)
+		}
+	}
+
 	license := &License{
-		ID:           licenseID,
-		ProductName:  options.ProductName,
-		Version:      options.Version,
-		MAC:          options.MAC,
-		UUID:         options.UUID,
-		CPUID:        options.CPUID,
-		IssuedAt:     now,
-		ExpiresAt:    now.Add(options.Duration),
-		Features:     options.Features,
-		MaxUsers:     options.MaxUsers,
-		CustomerName: options.CustomerName,
-		Notes:        options.Notes,
-		Extra:        options.Extra,
+		ID:               licenseID,
+		ProductName:      options.ProductName,
+		Version:          options.Version,
+		MAC:              options.MAC,
+		UUID:             options.UUID,
+		CPUID:            options.CPUID,
+		Machines:         options.Machines,
+		MaxMachines:      maxMachines,
+		MatchPolicy:      options.MatchPolicy,
+		IssuedAt:         now,
+		ExpiresAt:        expiresAt,
+		Features:         options.Features,
+		MaxUsers:         options.MaxUsers,
+		Products:         products,
+		CheckIn:          options.CheckIn,
+		RequireIntegrity: options.RequireIntegrity,
+		LeaseKey:         options.LeaseKey,
+		CustomerName:     options.CustomerName,
+		Notes:            options.Notes,
+		Extra:            options.Extra,
 	}
 
 	return license, nil
@@ -95,22 +156,38 @@ func (g *Generator) Generate(options *GenerateOptions) (*License, error) {
 
 // SaveToFile 将许可证保存到文件
 func (g *Generator) SaveToFile(license *License, filePath string) error {
+	fileData, err := g.Encode(license)
+	if err != nil {
+		return err
+	}
+
+	// 写入文件
+	err = os.WriteFile(filePath, fileData, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	return nil
+}
+
+// Encode 加密并签名许可证，返回许可证文件内容
+func (g *Generator) Encode(license *License) ([]byte, error) {
 	// 序列化许可证
 	licenseData, err := json.Marshal(license)
 	if err != nil {
-		return fmt.Errorf("failed to marshal license: %v", err)
+		return nil, fmt.Errorf("failed to marshal license: %v", err)
 	}
 
 	// 加密许可证数据
 	encryptedData, err := crypto.EncryptAES(licenseData, g.aesKey)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt license data: %v", err)
+		return nil, fmt.Errorf("failed to encrypt license data: %v", err)
 	}
 
 	// 对加密数据进行签名
 	signature, err := crypto.SignData(encryptedData, g.privateKey)
 	if err != nil {
-		return fmt.Errorf("failed to sign data: %v", err)
+		return nil, fmt.Errorf("failed to sign data: %v", err)
 	}
 
 	// 创建许可证文件
@@ -124,16 +201,19 @@ func (g *Generator) SaveToFile(license *License, filePath string) error {
 	// 序列化许可证文件
 	fileData, err := json.MarshalIndent(licenseFile, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal license file: %v", err)
+		return nil, fmt.Errorf("failed to marshal license file: %v", err)
 	}
 
-	// 写入文件
-	err = os.WriteFile(filePath, fileData, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write file: %v", err)
-	}
+	g.logIssued("license issued",
+		slog.String("license_id", license.ID),
+		slog.String("customer", license.CustomerName),
+		slog.String("product", license.ProductName),
+		slog.String("version", license.Version),
+		slog.Time("expires_at", license.ExpiresAt),
+		slog.Int("machines", len(license.AllowedMachines())),
+		slog.Any("features", license.Features))
 
-	return nil
+	return fileData, nil
 }
 
 // GetPublicKey 获取公钥
@@ -0,0 +1,47 @@
+package license_test
+
+import (
+	"testing"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+func TestGenerateMachineLimit(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	_, err = generator.Generate(&license.GenerateOptions{
+		Machines:    []machine.MachineInfo{{MAC: "aa:aa:aa:aa:aa:aa"}, {MAC: "bb:bb:bb:bb:bb:bb"}},
+		MaxMachines: 1,
+	})
+	if err == nil {
+		t.Error("Generate() should reject more machines than MaxMachines")
+	}
+
+	lic, err := generator.Generate(&license.GenerateOptions{
+		Machines: []machine.MachineInfo{{MAC: "aa:aa:aa:aa:aa:aa"}, {MAC: "bb:bb:bb:bb:bb:bb"}},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if lic.MaxMachines != 2 {
+		t.Errorf("Generate() MaxMachines = %d, want 2", lic.MaxMachines)
+	}
+}
+
+func TestGenerateRejectsEmptyMachine(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	_, err = generator.Generate(&license.GenerateOptions{
+		Machines: []machine.MachineInfo{{MAC: "aa:aa:aa:aa:aa:aa"}, {}},
+	})
+	if err == nil {
+		t.Error("Generate() should reject a machine without MAC, UUID or CPUID")
+	}
+}
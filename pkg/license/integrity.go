@@ -0,0 +1,173 @@
+package license
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DocumentTypeIntegrity 完整性清单的文档类型
+const DocumentTypeIntegrity = "integrity-manifest"
+
+// IntegrityManifest 完整性清单
+// 构建时记录产品可执行文件的哈希并签名，运行时重新计算当前可执行文件的哈希，被修改的程序无法通过验证
+type IntegrityManifest struct {
+	Product  string       `json:"product,omitempty"` // 产品名称，不为空时许可证必须授权该产品
+	Version  string       `json:"version,omitempty"` // 产品版本（仅用于说明）
+	IssuedAt time.Time    `json:"issued_at"`         // 签发时间
+	Binaries []BinaryHash `json:"binaries"`          // 可执行文件的哈希，可包含多个平台的构建
+}
+
+// BinaryHash 可执行文件的哈希
+type BinaryHash struct {
+	Name   string `json:"name"`   // 文件名
+	Size   int64  `json:"size"`   // 文件大小
+	SHA256 string `json:"sha256"` // SHA-256 哈希的十六进制
+}
+
+// HashFile 计算文件的哈希
+func HashFile(path string) (*BinaryHash, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return &BinaryHash{
+		Name:   filepath.Base(path),
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// IssueIntegrityManifest 计算可执行文件的哈希并签发完整性清单
+func (g *Generator) IssueIntegrityManifest(product, version string, paths ...string) ([]byte, *IntegrityManifest, error) {
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("no executable specified")
+	}
+
+	manifest := &IntegrityManifest{
+		Product:  product,
+		Version:  version,
+		IssuedAt: time.Now().UTC(),
+	}
+	for _, path := range paths {
+		binary, err := HashFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		manifest.Binaries = append(manifest.Binaries, *binary)
+	}
+
+	data, err := signDocument(DocumentTypeIntegrity, manifest, g.privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g.logIssued("integrity manifest issued",
+		slog.String("product", product),
+		slog.String("version", version),
+		slog.Int("binaries", len(manifest.Binaries)))
+	return data, manifest, nil
+}
+
+// ParseIntegrityManifest 校验签名并解析完整性清单
+func (v *Verifier) ParseIntegrityManifest(data []byte) (*IntegrityManifest, error) {
+	var manifest IntegrityManifest
+	if err := openDocument(data, DocumentTypeIntegrity, v.publicKey, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// Matches 检查哈希是否与清单中的某个可执行文件相同
+func (m *IntegrityManifest) Matches(binary *BinaryHash) bool {
+	for _, expected := range m.Binaries {
+		if expected.Size == binary.Size && expected.SHA256 == binary.SHA256 {
+			return true
+		}
+	}
+	return false
+}
+
+// coversProduct 检查许可证是否授权清单中的产品，清单未指定产品时不检查
+func (m *IntegrityManifest) coversProduct(license *License) bool {
+	if m.Product == "" || m.Product == license.ProductName {
+		return true
+	}
+	for _, grant := range license.Products {
+		if grant.Name == m.Product {
+			return true
+		}
+	}
+	return false
+}
+
+// executableHash 计算并缓存可执行文件的哈希，可执行文件在运行期间不会改变
+type executableHash struct {
+	path string // 为空时使用 os.Executable()
+
+	once   sync.Once
+	binary *BinaryHash
+	err    error
+}
+
+func (e *executableHash) get() (*BinaryHash, error) {
+	e.once.Do(func() {
+		path := e.path
+		if path == "" {
+			path, e.err = os.Executable()
+			if e.err != nil {
+				e.err = fmt.Errorf("failed to locate executable: %v", e.err)
+				return
+			}
+		}
+		e.binary, e.err = HashFile(path)
+	})
+	return e.binary, e.err
+}
+
+// checkIntegrity 检查当前可执行文件是否与完整性清单一致，以及清单是否属于许可证授权的产品
+// 许可证要求完整性检查时，未配置清单视为失败
+func (v *Verifier) checkIntegrity(result *VerificationResult, license *License) {
+	if v.integrity == nil {
+		if license.RequireIntegrity {
+			result.fail(CheckIntegrity, "integrity manifest", "none", ErrIntegrity,
+				"license requires an integrity manifest")
+			return
+		}
+		result.skip(CheckIntegrity, "no integrity manifest configured")
+		return
+	}
+
+	if !v.integrity.coversProduct(license) {
+		result.fail(CheckIntegrity, license.ProductName, v.integrity.Product, ErrIntegrity,
+			fmt.Sprintf("integrity manifest is for product %q, which the license does not cover", v.integrity.Product))
+		return
+	}
+
+	binary, err := v.executable.get()
+	if err != nil {
+		result.fail(CheckIntegrity, "", "", ErrIntegrity, err.Error())
+		return
+	}
+
+	if !v.integrity.Matches(binary) {
+		result.fail(CheckIntegrity, "signed executable hash", binary.SHA256, ErrIntegrity,
+			fmt.Sprintf("executable %s has been modified", binary.Name))
+		return
+	}
+	result.pass(CheckIntegrity, "signed executable hash", binary.SHA256)
+}
@@ -0,0 +1,185 @@
+package license_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func TestIntegrityManifest(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "app")
+	if err := os.WriteFile(executable, []byte("original program"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, parsed, err := generator.IssueIntegrityManifest("Editor", "1.0.0", executable)
+	if err != nil {
+		t.Fatalf("IssueIntegrityManifest() error = %v", err)
+	}
+	if len(parsed.Binaries) != 1 || parsed.Binaries[0].Name != "app" {
+		t.Fatalf("IssueIntegrityManifest() binaries = %+v", parsed.Binaries)
+	}
+
+	_, verifier, _, _ := newTestSetupWithKeys(t, generator,
+		license.WithIntegrityManifest(manifest), license.WithExecutable(executable))
+	data := issue(t, generator, &license.GenerateOptions{ProductName: "Editor", Duration: 24 * time.Hour})
+
+	result, err := verifier.Verify(data)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+
+	// 修改后的可执行文件无法通过验证
+	if err := os.WriteFile(executable, []byte("patched program!"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, patched, _, _ := newTestSetupWithKeys(t, generator,
+		license.WithIntegrityManifest(manifest), license.WithExecutable(executable))
+
+	result, err = patched.Verify(data)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Valid || !errors.Is(result.Err, license.ErrIntegrity) {
+		t.Errorf("Verify() patched executable Err = %v, want ErrIntegrity", result.Err)
+	}
+	var verr *license.VerificationError
+	if !errors.As(result.Err, &verr) || verr.Check != license.CheckIntegrity {
+		t.Errorf("Verify() failed check = %v, want %s", result.Err, license.CheckIntegrity)
+	}
+}
+
+func TestIntegrityManifestRejected(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	other, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	executable := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(executable, []byte("program"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他密钥签发的清单
+	manifest, _, err := other.IssueIntegrityManifest("", "", executable)
+	if err != nil {
+		t.Fatalf("IssueIntegrityManifest() error = %v", err)
+	}
+
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	if _, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(), license.WithIntegrityManifest(manifest)); err == nil {
+		t.Error("NewVerifier() should reject a manifest signed by another key")
+	}
+
+	// 其他类型的签名文档
+	token, err := generator.IssueTimeToken(time.Time{})
+	if err != nil {
+		t.Fatalf("IssueTimeToken() error = %v", err)
+	}
+	if _, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(), license.WithIntegrityManifest(token)); err == nil {
+		t.Error("NewVerifier() should reject a time token as integrity manifest")
+	}
+}
+
+func TestIntegritySkippedWithoutManifest(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{Duration: 24 * time.Hour})
+
+	result, err := verifier.Verify(data)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	for _, check := range result.Checks {
+		if check.Name == license.CheckIntegrity && check.Status != license.CheckSkipped {
+			t.Errorf("integrity check status = %s, want skip", check.Status)
+		}
+	}
+}
+
+func TestIntegrityRequired(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{Duration: 24 * time.Hour, RequireIntegrity: true})
+
+	// 许可证要求完整性检查时，缺少清单验证失败
+	result, err := verifier.Verify(data)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Valid || !errors.Is(result.Err, license.ErrIntegrity) {
+		t.Errorf("Verify() without manifest Err = %v, want ErrIntegrity", result.Err)
+	}
+
+	executable := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(executable, []byte("program"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _, err := generator.IssueIntegrityManifest("", "", executable)
+	if err != nil {
+		t.Fatalf("IssueIntegrityManifest() error = %v", err)
+	}
+	_, verifier, _, _ = newTestSetupWithKeys(t, generator,
+		license.WithIntegrityManifest(manifest), license.WithExecutable(executable))
+	if result, _ := verifier.Verify(data); !result.Valid {
+		t.Errorf("Verify() with manifest invalid: %s", result.Error)
+	}
+}
+
+func TestIntegrityManifestProduct(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	executable := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(executable, []byte("program"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _, err := generator.IssueIntegrityManifest("Editor", "1.0.0", executable)
+	if err != nil {
+		t.Fatalf("IssueIntegrityManifest() error = %v", err)
+	}
+	_, verifier, _, _ := newTestSetupWithKeys(t, generator,
+		license.WithIntegrityManifest(manifest), license.WithExecutable(executable))
+
+	tests := []struct {
+		name    string
+		options *license.GenerateOptions
+		valid   bool
+	}{
+		{"same product", &license.GenerateOptions{ProductName: "Editor"}, true},
+		{"product grant", &license.GenerateOptions{Products: []license.ProductGrant{{Name: "Viewer"}, {Name: "Editor"}}}, true},
+		{"other product", &license.GenerateOptions{ProductName: "Viewer"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Duration = 24 * time.Hour
+			result, err := verifier.Verify(issue(t, generator, tt.options))
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if result.Valid != tt.valid {
+				t.Errorf("Verify() valid = %v, want %v (%s)", result.Valid, tt.valid, result.Error)
+			}
+			if !tt.valid && !errors.Is(result.Err, license.ErrIntegrity) {
+				t.Errorf("Verify() Err = %v, want ErrIntegrity", result.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package license
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// DocumentTypeLease 签到租约的文档类型
+const DocumentTypeLease = "lease"
+
+// Lease 签到租约
+// 由签到服务器在许可证签到成功后签发，许可证在 ExpiresAt 之前有效
+type Lease struct {
+	LicenseID string    `json:"license_id"` // 许可证ID
+	IssuedAt  time.Time `json:"issued_at"`  // 签到时间
+	ExpiresAt time.Time `json:"expires_at"` // 租约过期时间
+}
+
+// Validate 检查签到策略是否有效
+func (p *CheckInPolicy) Validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("check-in URL must be an absolute http(s) URL: %q", p.URL)
+	}
+	if p.LeaseDays <= 0 {
+		return fmt.Errorf("lease days must be positive")
+	}
+	return nil
+}
+
+// LeaseDuration 返回每次签到获得的租约有效期
+func (p *CheckInPolicy) LeaseDuration() time.Duration {
+	return time.Duration(p.LeaseDays) * 24 * time.Hour
+}
+
+// IssueLease 为许可证签发签到租约，有效期从当前时间开始计算，不超过许可证的过期时间
+func (g *Generator) IssueLease(license *License) ([]byte, *Lease, error) {
+	if license.CheckIn == nil {
+		return nil, nil, fmt.Errorf("license %s does not require check-in", license.ID)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(license.CheckIn.LeaseDuration())
+	if expiresAt.After(license.ExpiresAt) {
+		expiresAt = license.ExpiresAt
+	}
+
+	lease := &Lease{
+		LicenseID: license.ID,
+		IssuedAt:  now,
+		ExpiresAt: expiresAt,
+	}
+
+	data, err := signDocument(DocumentTypeLease, lease, g.privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g.logIssued("lease issued",
+		slog.String("license_id", lease.LicenseID),
+		slog.Time("expires_at", lease.ExpiresAt))
+	return data, lease, nil
+}
+
+// ParseLease 校验签名并解析签到租约
+func (v *Verifier) ParseLease(data []byte) (*Lease, error) {
+	var lease Lease
+	if err := openDocument(data, DocumentTypeLease, v.publicKey, &lease); err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// checkLease 检查包含签到策略的许可证是否有未过期的签到租约
+func (v *Verifier) checkLease(result *VerificationResult, license *License, now time.Time) {
+	if license.CheckIn == nil {
+		result.skip(CheckLease, "license does not require check-in")
+		return
+	}
+
+	if v.leaseLoader == nil {
+		result.fail(CheckLease, "valid lease", "no lease", ErrLeaseExpired,
+			fmt.Sprintf("license requires check-in at %s", license.CheckIn.URL))
+		return
+	}
+
+	data, err := v.leaseLoader()
+	if err != nil {
+		result.fail(CheckLease, "valid lease", "no lease", ErrLeaseExpired, err.Error())
+		return
+	}
+
+	lease, err := v.ParseLease(data)
+	if err != nil {
+		result.fail(CheckLease, "valid lease", "invalid lease", ErrLeaseExpired, fmt.Sprintf("invalid lease: %v", err))
+		return
+	}
+
+	if lease.LicenseID != license.ID {
+		result.fail(CheckLease, license.ID, lease.LicenseID, ErrLeaseExpired, "lease was issued for another license")
+		return
+	}
+
+	expected := "before " + lease.ExpiresAt.Format(time.RFC3339)
+	if now.After(lease.ExpiresAt) {
+		result.fail(CheckLease, expected, now.Format(time.RFC3339), ErrLeaseExpired,
+			fmt.Sprintf("check-in lease expired at %s, please check in at %s",
+				lease.ExpiresAt.Format(time.RFC3339), license.CheckIn.URL))
+		return
+	}
+	result.pass(CheckLease, expected, now.Format(time.RFC3339))
+
+	if expiresIn := int64(lease.ExpiresAt.Sub(now).Seconds()); expiresIn < result.ExpiresIn {
+		result.ExpiresIn = expiresIn
+	}
+}
@@ -0,0 +1,71 @@
+package license_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func TestLeaseRequired(t *testing.T) {
+	generator, _, _, _ := newTestSetup(t)
+	lic, err := generator.Generate(&license.GenerateOptions{
+		Duration: 365 * 24 * time.Hour,
+		CheckIn:  &license.CheckInPolicy{URL: "https://license.example.com/v1/checkin", LeaseDays: 7},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	data, _ := generator.Encode(lic)
+
+	// 没有租约
+	_, verifier, _, _ := newTestSetupWithKeys(t, generator)
+	result, _ := verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrLeaseExpired) {
+		t.Errorf("Verify() without lease Err = %v, want %v", result.Err, license.ErrLeaseExpired)
+	}
+
+	lease, _, err := generator.IssueLease(lic)
+	if err != nil {
+		t.Fatalf("IssueLease() error = %v", err)
+	}
+	_, verifier, clock, _ := newTestSetupWithKeys(t, generator, license.WithLease(lease))
+	result, _ = verifier.Verify(data)
+	if !result.Valid {
+		t.Fatalf("Verify() with lease invalid: %s", result.Error)
+	}
+	if result.ExpiresIn > int64((7 * 24 * time.Hour).Seconds()) {
+		t.Errorf("Verify() ExpiresIn = %d, want limited by the lease", result.ExpiresIn)
+	}
+
+	// 租约过期
+	clock.Advance(8 * 24 * time.Hour)
+	result, _ = verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrLeaseExpired) {
+		t.Errorf("Verify() with expired lease Err = %v, want %v", result.Err, license.ErrLeaseExpired)
+	}
+
+	// 其他许可证的租约
+	other, _ := generator.Generate(&license.GenerateOptions{
+		CheckIn: &license.CheckInPolicy{URL: "https://license.example.com/v1/checkin", LeaseDays: 7},
+	})
+	otherLease, _, _ := generator.IssueLease(other)
+	_, verifier, _, _ = newTestSetupWithKeys(t, generator, license.WithLease(otherLease))
+	result, _ = verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrLeaseExpired) {
+		t.Errorf("Verify() with another license's lease Err = %v, want %v", result.Err, license.ErrLeaseExpired)
+	}
+}
+
+func TestCheckInPolicyValidate(t *testing.T) {
+	generator, _, _, _ := newTestSetup(t)
+	for _, policy := range []*license.CheckInPolicy{
+		{URL: "license.example.com", LeaseDays: 7},
+		{URL: "https://license.example.com/v1/checkin"},
+	} {
+		if _, err := generator.Generate(&license.GenerateOptions{CheckIn: policy}); err == nil {
+			t.Errorf("Generate() with policy %+v should fail", policy)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package licensetest 提供测试许可证验证逻辑所需的假时钟和假机器信息提供者
+//
+//	clock := licensetest.NewClock(time.Now())
+//	info := licensetest.NewMachineInfo("00:11:22:33:44:55", "uuid", "cpuid")
+//	verifier, _ := license.NewVerifier(publicKeyPEM, aesKey,
+//		license.WithClock(clock), license.WithMachineInfoProvider(info))
+//	clock.Advance(366 * 24 * time.Hour) // 模拟许可证过期
+package licensetest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// Clock 可手动控制的时钟，可安全地并发使用
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock 创建指向指定时间的时钟
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now 返回时钟的当前时间
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set 将时钟设置为指定时间
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance 将时钟向前（d 为负数时向后）拨动
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// MachineInfo 返回固定机器信息的提供者，可安全地并发使用
+type MachineInfo struct {
+	mu    sync.Mutex
+	info  machine.MachineInfo
+	err   error
+	calls int
+}
+
+// NewMachineInfo 创建返回指定机器信息的提供者
+func NewMachineInfo(mac, uuid, cpuid string) *MachineInfo {
+	return &MachineInfo{
+		info: machine.MachineInfo{MAC: mac, UUID: uuid, CPUID: cpuid},
+	}
+}
+
+// GetAllInfo 返回机器信息的副本
+func (m *MachineInfo) GetAllInfo() (*machine.MachineInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	info := m.info
+	return &info, nil
+}
+
+// Set 替换机器信息，用于模拟硬件变更
+func (m *MachineInfo) Set(info machine.MachineInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.info = info
+}
+
+// SetError 设置 GetAllInfo 返回的错误，传入 nil 恢复正常
+func (m *MachineInfo) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Calls 返回 GetAllInfo 被调用的次数
+func (m *MachineInfo) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
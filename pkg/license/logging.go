@@ -0,0 +1,91 @@
+package license
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+)
+
+// SetLogger 设置生成器的日志记录器，签发许可证和签名文档时输出结构化日志，nil 表示不输出日志
+func (g *Generator) SetLogger(logger *slog.Logger) {
+	g.logger = logger
+}
+
+// WithLogger 设置验证器的日志记录器，每次验证输出一条结构化日志：
+// 验证通过为 Info 级别，失败为 Warn 级别并包含失败的检查项和原因
+func WithLogger(logger *slog.Logger) Option {
+	return func(v *Verifier) {
+		v.logger = logger
+	}
+}
+
+// logIssued 记录生成器签发的文档
+func (g *Generator) logIssued(msg string, attrs ...slog.Attr) {
+	if g.logger == nil {
+		return
+	}
+
+	if keyID, err := crypto.KeyID(&g.privateKey.PublicKey); err == nil {
+		attrs = append(attrs, slog.String("key_id", keyID))
+	}
+	g.logger.LogAttrs(context.Background(), slog.LevelInfo, msg, attrs...)
+}
+
+// logResult 记录验证结果
+func (v *Verifier) logResult(ctx context.Context, result *VerificationResult) {
+	if v.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("key_id", v.keyID)}
+	if result.Cached {
+		attrs = append(attrs, slog.Bool("cached", true))
+	}
+	if result.License != nil {
+		attrs = append(attrs,
+			slog.String("license_id", result.License.ID),
+			slog.String("customer", result.License.CustomerName),
+			slog.String("product", result.License.ProductName),
+			slog.Time("expires_at", result.License.ExpiresAt))
+	}
+
+	if result.Valid {
+		attrs = append(attrs, slog.Int64("expires_in", result.ExpiresIn))
+		v.logger.LogAttrs(ctx, slog.LevelInfo, "license verified", attrs...)
+		return
+	}
+
+	var failed []string
+	machineFailed := false
+	for _, check := range result.FailedChecks() {
+		failed = append(failed, check.Name)
+		machineFailed = machineFailed || check.Name == CheckMachine
+	}
+	attrs = append(attrs,
+		slog.String("reason", result.Error),
+		slog.Any("failed_checks", failed))
+
+	var verr *VerificationError
+	if errors.As(result.Err, &verr) {
+		attrs = append(attrs,
+			slog.String("check", verr.Check),
+			slog.String("error", verr.Err.Error()))
+	}
+
+	// 机器不匹配时记录不匹配的组件
+	if machineFailed {
+		var mismatched []any
+		for _, component := range result.MachineInfo.Components {
+			if !component.Matched {
+				mismatched = append(mismatched, slog.Group(component.Component,
+					slog.String("expected", component.Expected),
+					slog.String("actual", component.Actual)))
+			}
+		}
+		attrs = append(attrs, slog.Group("machine", mismatched...))
+	}
+
+	v.logger.LogAttrs(ctx, slog.LevelWarn, "license verification failed", attrs...)
+}
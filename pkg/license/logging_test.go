@@ -0,0 +1,114 @@
+package license_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// logEntries 解析 JSON 格式的日志
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	buf.Reset()
+	return entries
+}
+
+func TestGeneratorLogging(t *testing.T) {
+	var buf bytes.Buffer
+	generator, _, _, _ := newTestSetup(t)
+	generator.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	lic, err := generator.Generate(&license.GenerateOptions{CustomerName: "ACME", Duration: time.Hour})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if _, err := generator.Encode(lic); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry["msg"] != "license issued" || entry["license_id"] != lic.ID || entry["customer"] != "ACME" {
+		t.Errorf("unexpected log entry: %v", entry)
+	}
+	if entry["key_id"] == "" || entry["key_id"] == nil {
+		t.Errorf("log entry missing key_id: %v", entry)
+	}
+}
+
+func TestVerifierLogging(t *testing.T) {
+	var buf bytes.Buffer
+	generator, verifier, _, _ := newTestSetup(t, license.WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	data := issue(t, generator, &license.GenerateOptions{
+		MAC:      testMAC,
+		UUID:     testUUID,
+		CPUID:    testCPUID,
+		Duration: 24 * time.Hour,
+	})
+	if _, err := verifier.Verify(data); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["msg"] != "license verified" || entries[0]["level"] != "INFO" {
+		t.Fatalf("unexpected log entries: %v", entries)
+	}
+
+	// 机器不匹配时记录不匹配的组件
+	data = issue(t, generator, &license.GenerateOptions{
+		MAC:      "00:00:00:00:00:00",
+		UUID:     testUUID,
+		CPUID:    testCPUID,
+		Duration: 24 * time.Hour,
+	})
+	if _, err := verifier.Verify(data); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	entries = logEntries(t, &buf)
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry["msg"] != "license verification failed" || entry["level"] != "WARN" || entry["check"] != license.CheckMachine {
+		t.Errorf("unexpected log entry: %v", entry)
+	}
+	machine, _ := entry["machine"].(map[string]interface{})
+	mac, _ := machine["mac"].(map[string]interface{})
+	if mac["expected"] != "00:00:00:00:00:00" || mac["actual"] != testMAC {
+		t.Errorf("log entry machine details = %v", entry["machine"])
+	}
+	if _, ok := machine["uuid"]; ok {
+		t.Errorf("log entry should only list mismatched components: %v", machine)
+	}
+
+	// 读取失败也会记录
+	if _, err := verifier.VerifyFile("missing.lic"); err != nil {
+		t.Fatalf("VerifyFile() error = %v", err)
+	}
+	entries = logEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["check"] != license.CheckFormat {
+		t.Errorf("unexpected log entries for missing file: %v", entries)
+	}
+}
@@ -0,0 +1,99 @@
+package license
+
+import (
+	"fmt"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// AllowedMachines 返回许可证允许的所有机器
+// 包括单机绑定字段（MAC/UUID/CPUID）和多机器绑定列表，返回空列表表示不绑定机器
+func (l *License) AllowedMachines() []machine.MachineInfo {
+	var machines []machine.MachineInfo
+
+	if l.MAC != "" || l.UUID != "" || l.CPUID != "" {
+		machines = append(machines, machine.MachineInfo{
+			MAC:   l.MAC,
+			UUID:  l.UUID,
+			CPUID: l.CPUID,
+		})
+	}
+
+	return append(machines, l.Machines...)
+}
+
+// Validate 检查匹配策略是否有效
+func (p *MatchPolicy) Validate() error {
+	known := make(map[string]bool)
+	for _, name := range machine.ComponentNames {
+		known[name] = true
+	}
+
+	for name, weight := range p.Weights {
+		if !known[name] {
+			return fmt.Errorf("unknown machine component %q", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("weight of machine component %q cannot be negative", name)
+		}
+	}
+
+	if p.MinMatches < 0 || p.MinMatches > len(machine.ComponentNames) {
+		return fmt.Errorf("min matches must be between 0 and %d", len(machine.ComponentNames))
+	}
+	if p.MinScore < 0 {
+		return fmt.Errorf("min score cannot be negative")
+	}
+
+	return nil
+}
+
+// weight 返回组件的权重
+func (p *MatchPolicy) weight(component string) int {
+	if p != nil {
+		if w, ok := p.Weights[component]; ok {
+			return w
+		}
+	}
+	return 1
+}
+
+// matchMachine 按匹配策略比较当前机器与许可证中的机器信息
+// 只比较许可证中非空的组件，返回各组件的匹配情况、匹配组件的权重总分和是否匹配
+func matchMachine(expected machine.MachineInfo, actual *machine.MachineInfo, policy *MatchPolicy) ([]ComponentMatch, int, bool) {
+	var components []ComponentMatch
+	matches, score := 0, 0
+
+	for _, name := range machine.ComponentNames {
+		value := expected.Component(name)
+		if value == "" {
+			continue
+		}
+
+		cm := ComponentMatch{
+			Component: name,
+			Expected:  value,
+			Actual:    actual.Component(name),
+			Weight:    policy.weight(name),
+		}
+		cm.Matched = cm.Expected == cm.Actual
+		if cm.Matched {
+			matches++
+			score += cm.Weight
+		}
+		components = append(components, cm)
+	}
+
+	// 没有绑定任何组件的条目不匹配任何机器
+	if len(components) == 0 {
+		return components, score, false
+	}
+
+	// 未设置策略时要求所有组件匹配
+	if policy == nil || (policy.MinMatches == 0 && policy.MinScore == 0) {
+		return components, score, matches == len(components)
+	}
+
+	matched := matches >= policy.MinMatches && score >= policy.MinScore
+	return components, score, matched
+}
@@ -0,0 +1,105 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+func TestAllowedMachines(t *testing.T) {
+	lic := &License{
+		MAC: "00:11:22:33:44:55",
+		Machines: []machine.MachineInfo{
+			{MAC: "aa:aa:aa:aa:aa:aa"},
+			{UUID: "uuid-2"},
+		},
+	}
+
+	machines := lic.AllowedMachines()
+	if len(machines) != 3 {
+		t.Fatalf("AllowedMachines() = %d machines, want 3", len(machines))
+	}
+	if machines[0].MAC != "00:11:22:33:44:55" {
+		t.Errorf("AllowedMachines()[0] = %+v, want single-machine binding first", machines[0])
+	}
+
+	if machines := (&License{}).AllowedMachines(); len(machines) != 0 {
+		t.Errorf("AllowedMachines() without binding = %+v, want none", machines)
+	}
+}
+
+func TestMatchMachine(t *testing.T) {
+	actual := &machine.MachineInfo{MAC: "00:11:22:33:44:55", UUID: "uuid-1", CPUID: "cpu-1"}
+
+	tests := []struct {
+		name     string
+		expected machine.MachineInfo
+		want     bool
+	}{
+		{"all components", machine.MachineInfo{MAC: "00:11:22:33:44:55", UUID: "uuid-1", CPUID: "cpu-1"}, true},
+		{"mac only", machine.MachineInfo{MAC: "00:11:22:33:44:55"}, true},
+		{"other mac", machine.MachineInfo{MAC: "aa:aa:aa:aa:aa:aa"}, false},
+		{"other cpu", machine.MachineInfo{MAC: "00:11:22:33:44:55", CPUID: "cpu-2"}, false},
+		{"empty entry", machine.MachineInfo{}, false},
+	}
+
+	for _, tt := range tests {
+		if _, _, got := matchMachine(tt.expected, actual, nil); got != tt.want {
+			t.Errorf("%s: matchMachine() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchMachinePolicy(t *testing.T) {
+	expected := machine.MachineInfo{MAC: "00:11:22:33:44:55", UUID: "uuid-1", CPUID: "cpu-1"}
+	// 更换了网卡
+	actual := &machine.MachineInfo{MAC: "aa:aa:aa:aa:aa:aa", UUID: "uuid-1", CPUID: "cpu-1"}
+
+	tests := []struct {
+		name      string
+		policy    *MatchPolicy
+		wantScore int
+		want      bool
+	}{
+		{"no policy", nil, 2, false},
+		{"2 of 3", &MatchPolicy{MinMatches: 2}, 2, true},
+		{"3 of 3", &MatchPolicy{MinMatches: 3}, 2, false},
+		{"weighted", &MatchPolicy{MinScore: 4, Weights: map[string]int{machine.ComponentCPUID: 3}}, 4, true},
+		{"weighted mac", &MatchPolicy{MinScore: 4, Weights: map[string]int{machine.ComponentMAC: 3}}, 2, false},
+	}
+
+	for _, tt := range tests {
+		components, score, got := matchMachine(expected, actual, tt.policy)
+		if got != tt.want || score != tt.wantScore {
+			t.Errorf("%s: matchMachine() = %v, score %d, want %v, score %d", tt.name, got, score, tt.want, tt.wantScore)
+		}
+		if len(components) != 3 {
+			t.Errorf("%s: matchMachine() compared %d components, want 3", tt.name, len(components))
+		}
+	}
+
+	// 没有绑定组件的条目在任何策略下都不匹配
+	if _, _, got := matchMachine(machine.MachineInfo{}, actual, &MatchPolicy{Weights: map[string]int{machine.ComponentMAC: 2}}); got {
+		t.Error("matchMachine() with empty entry = true, want false")
+	}
+}
+
+func TestMatchPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  MatchPolicy
+		wantErr bool
+	}{
+		{"valid", MatchPolicy{MinMatches: 2, Weights: map[string]int{machine.ComponentUUID: 2}}, false},
+		{"unknown component", MatchPolicy{Weights: map[string]int{"disk": 1}}, true},
+		{"negative weight", MatchPolicy{Weights: map[string]int{machine.ComponentMAC: -1}}, true},
+		{"too many matches", MatchPolicy{MinMatches: 4}, true},
+		{"negative score", MatchPolicy{MinScore: -1}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
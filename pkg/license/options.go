@@ -0,0 +1,185 @@
+package license
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+// Clock 时钟接口，验证器通过它获取当前时间
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc 将函数适配为 Clock
+type ClockFunc func() time.Time
+
+// Now 返回当前时间
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// MachineInfoProvider 机器信息提供者接口，验证器通过它获取当前机器信息
+type MachineInfoProvider interface {
+	GetAllInfo() (*machine.MachineInfo, error)
+}
+
+// ContextMachineInfoProvider 支持 context 的机器信息提供者，VerifyContext 会优先使用该接口
+type ContextMachineInfoProvider interface {
+	GetAllInfoContext(ctx context.Context) (*machine.MachineInfo, error)
+}
+
+// MachineInfoFunc 将函数适配为 MachineInfoProvider
+type MachineInfoFunc func() (*machine.MachineInfo, error)
+
+// GetAllInfo 返回机器信息
+func (f MachineInfoFunc) GetAllInfo() (*machine.MachineInfo, error) {
+	return f()
+}
+
+// systemClock 使用系统时间的时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// systemMachineInfo 从本机获取机器信息
+type systemMachineInfo struct{}
+
+func (systemMachineInfo) GetAllInfo() (*machine.MachineInfo, error) {
+	return machine.GetAllInfo()
+}
+
+func (systemMachineInfo) GetAllInfoContext(ctx context.Context) (*machine.MachineInfo, error) {
+	return machine.GetAllInfoContext(ctx)
+}
+
+// getMachineInfo 在 ctx 的限制内获取机器信息
+// 提供者不支持 context 时在单独的 goroutine 中调用，ctx 结束后不再等待其返回
+func getMachineInfo(ctx context.Context, provider MachineInfoProvider) (*machine.MachineInfo, error) {
+	if p, ok := provider.(ContextMachineInfoProvider); ok {
+		return p.GetAllInfoContext(ctx)
+	}
+
+	type reply struct {
+		info *machine.MachineInfo
+		err  error
+	}
+	done := make(chan reply, 1)
+	go func() {
+		info, err := provider.GetAllInfo()
+		done <- reply{info, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.info, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Option 验证器选项
+type Option func(*Verifier)
+
+// WithClock 设置验证器使用的时钟
+func WithClock(clock Clock) Option {
+	return func(v *Verifier) {
+		v.clock = clock
+		v.fixedTime = false
+	}
+}
+
+// WithTime 以指定时间验证许可证（例如检查许可证在某一天是否有效）
+// 指定的时间不是真实时间，验证通过后不会记录到时间存储
+func WithTime(t time.Time) Option {
+	return func(v *Verifier) {
+		v.clock = ClockFunc(func() time.Time { return t })
+		v.fixedTime = true
+	}
+}
+
+// WithMachineInfoProvider 设置验证器使用的机器信息提供者
+func WithMachineInfoProvider(provider MachineInfoProvider) Option {
+	return func(v *Verifier) {
+		v.machineInfo = provider
+	}
+}
+
+// WithProduct 设置验证的产品范围，等同于调用 SetProduct
+func WithProduct(name, version string) Option {
+	return func(v *Verifier) {
+		v.SetProduct(name, version)
+	}
+}
+
+// WithTimeStore 启用时钟回拨检测
+// 每次验证成功后记录验证时间，当前时间早于记录时间超过 tolerance 时验证失败
+func WithTimeStore(store TimeStore, tolerance time.Duration) Option {
+	return func(v *Verifier) {
+		v.timeStore = store
+		v.clockTolerance = tolerance
+	}
+}
+
+// WithTimeToken 使用可信时间令牌（由 lkctl timestamp 签发）作为当前时间的下限
+// 可以多次使用，以最新的令牌为准；令牌无效时 NewVerifier 返回错误
+func WithTimeToken(data []byte) Option {
+	return func(v *Verifier) {
+		v.timeTokens = append(v.timeTokens, data)
+	}
+}
+
+// WithRevocationList 加载吊销列表（由 lkctl revoke 生成），列表无效时 NewVerifier 返回错误
+func WithRevocationList(data []byte) Option {
+	return func(v *Verifier) {
+		v.revocationLists = append(v.revocationLists, data)
+	}
+}
+
+// WithObserver 每次验证后以验证结果调用 observe（包括读取许可证失败的情况），可以多次设置
+// observe 在验证的 goroutine 中同步调用，不应阻塞或修改验证结果
+func WithObserver(observe func(*VerificationResult)) Option {
+	return func(v *Verifier) {
+		v.observers = append(v.observers, observe)
+	}
+}
+
+// WithIntegrityManifest 使用完整性清单（由 lkctl integrity 签发），当前可执行文件被修改时验证失败
+// 清单无效时 NewVerifier 返回错误
+func WithIntegrityManifest(data []byte) Option {
+	return func(v *Verifier) {
+		v.integrityManifest = data
+	}
+}
+
+// WithExecutable 指定完整性检查的可执行文件，默认为 os.Executable()
+func WithExecutable(path string) Option {
+	return func(v *Verifier) {
+		v.executable.path = path
+	}
+}
+
+// WithLease 使用签到租约（由签到服务器签发），只对包含签到策略的许可证生效
+func WithLease(data []byte) Option {
+	return func(v *Verifier) {
+		v.leaseLoader = func() ([]byte, error) { return data, nil }
+	}
+}
+
+// WithLeaseFile 每次验证时从文件读取签到租约，配合在后台定期签到并更新该文件的 checkin.Client 使用
+func WithLeaseFile(path string) Option {
+	return func(v *Verifier) {
+		v.leaseLoader = func() ([]byte, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read lease file: %v", err)
+			}
+			return data, nil
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package license
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GrantFor 查找指定产品及版本的授权
+// 对于没有产品授权列表的旧格式许可证，使用许可证本身的产品名称、功能列表和过期时间
+func (l *License) GrantFor(productName, version string) (*ProductGrant, error) {
+	if len(l.Products) == 0 {
+		if l.ProductName != productName {
+			return nil, fmt.Errorf("license is not valid for product %q", productName)
+		}
+		return &ProductGrant{
+			Name:      l.ProductName,
+			Features:  l.Features,
+			ExpiresAt: l.ExpiresAt,
+		}, nil
+	}
+
+	found := false
+	for i := range l.Products {
+		grant := &l.Products[i]
+		if grant.Name != productName {
+			continue
+		}
+		found = true
+		if version == "" || grant.CoversVersion(version) {
+			return grant, nil
+		}
+	}
+
+	if found {
+		return nil, fmt.Errorf("license does not cover version %s of product %q", version, productName)
+	}
+	return nil, fmt.Errorf("license is not valid for product %q", productName)
+}
+
+// CoversVersion 判断版本是否在授权的版本范围内
+func (g *ProductGrant) CoversVersion(version string) bool {
+	if g.MinVersion != "" && compareVersions(version, g.MinVersion) < 0 {
+		return false
+	}
+	if g.MaxVersion != "" && compareVersions(version, g.MaxVersion) > 0 {
+		return false
+	}
+	return true
+}
+
+// compareVersions 比较两个点分隔的版本号，返回 -1、0 或 1
+// 每部分先按开头的数字比较，再按剩余部分比较，缺失的部分视为 0；
+// "-" 之后为预发布标签，预发布版本低于对应的正式版本，"+" 之后的构建信息不参与比较
+func compareVersions(a, b string) int {
+	releaseA, preA := splitVersion(a)
+	releaseB, preB := splitVersion(b)
+
+	for i := 0; i < len(releaseA) || i < len(releaseB); i++ {
+		partA, partB := "0", "0"
+		if i < len(releaseA) {
+			partA = releaseA[i]
+		}
+		if i < len(releaseB) {
+			partB = releaseB[i]
+		}
+		if c := compareVersionPart(partA, partB); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case preA == nil && preB == nil:
+		return 0
+	case preA == nil:
+		return 1
+	case preB == nil:
+		return -1
+	}
+
+	// 预发布标签逐部分比较，前面的部分都相同时部分较少的较低
+	for i := 0; i < len(preA) && i < len(preB); i++ {
+		if c := compareVersionPart(preA[i], preB[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(preA), len(preB))
+}
+
+// splitVersion 将版本号拆分为正式版本部分和预发布标签部分，没有预发布标签时后者为 nil
+func splitVersion(version string) (release, prerelease []string) {
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "+")
+	version, pre, hasPre := strings.Cut(version, "-")
+
+	release = strings.Split(version, ".")
+	if hasPre {
+		prerelease = strings.Split(pre, ".")
+	}
+	return release, prerelease
+}
+
+// compareVersionPart 比较版本号的一部分
+// 两者都以数字开头时先按数值比较再按剩余部分比较，纯字母的部分高于以数字开头的部分
+func compareVersionPart(a, b string) int {
+	numA, restA, okA := splitNumber(a)
+	numB, restB, okB := splitNumber(b)
+
+	switch {
+	case okA && okB:
+		if c := cmp.Compare(numA, numB); c != 0 {
+			return c
+		}
+		return strings.Compare(restA, restB)
+	case okA:
+		return -1
+	case okB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// splitNumber 拆分开头的数字和剩余部分，不以数字开头时 ok 为 false
+func splitNumber(part string) (num uint64, rest string, ok bool) {
+	end := 0
+	for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, part, false
+	}
+
+	num, err := strconv.ParseUint(part[:end], 10, 64)
+	if err != nil {
+		num = math.MaxUint64
+	}
+	return num, part[end:], true
+}
+
+// HasFeature 判断许可证是否允许指定功能
+// 设置了验证产品时检查该产品授权的功能列表，否则检查许可证本身的功能列表
+func (r *VerificationResult) HasFeature(feature string) bool {
+	var features []string
+	switch {
+	case r.Grant != nil:
+		features = r.Grant.Features
+	case r.License != nil:
+		features = r.License.Features
+	}
+
+	for _, f := range features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package license
+
+import (
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"v1.2.0", "1.2", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.9.9", "2.0", -1},
+		{"2.0.0-beta", "2.0.0-alpha", 1},
+		{"2.0.0-beta", "2.0.0", -1},
+		{"2.0.0", "2.0.0-rc.1", 1},
+		{"1.10-rc", "1.9", 1},
+		{"1.10-rc", "1.10", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"1.2a", "1.2b", -1},
+		{"1.10a", "1.9b", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestHasFeature(t *testing.T) {
+	result := &VerificationResult{License: &License{Features: []string{"basic", "reports"}}}
+	if !result.HasFeature("reports") || result.HasFeature("export") {
+		t.Error("HasFeature() should use the license features")
+	}
+
+	// 设置了验证产品时使用产品授权的功能列表
+	result.Grant = &ProductGrant{Name: "Editor", Features: []string{"export"}}
+	if result.HasFeature("reports") || !result.HasFeature("export") {
+		t.Error("HasFeature() should use the grant features")
+	}
+}
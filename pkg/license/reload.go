@@ -0,0 +1,165 @@
+package license
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReloadableVerifier 可热加载的验证器
+// 从文件加载公钥、AES密钥和许可证，文件变化（或调用 Reload）时重新加载。
+// 新的密钥和许可证必须验证通过才会替换旧的，替换是原子的，可以被多个 goroutine 并发读取。
+type ReloadableVerifier struct {
+	publicKeyPath string
+	aesKeyPath    string
+	licensePath   string
+	opts          []Option
+
+	mu    sync.Mutex // 串行化 Reload
+	state atomic.Pointer[reloadState]
+}
+
+// reloadState 一次加载的结果
+type reloadState struct {
+	verifier    *Verifier
+	licenseData []byte
+	result      *VerificationResult
+	files       [3]fileStamp
+}
+
+// fileStamp 用于检测文件变化的修改时间和大小
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewReloadableVerifier 从文件创建可热加载的验证器，opts 在每次重新加载时应用到新的验证器
+// 初次加载的许可证必须有效，否则返回验证错误
+func NewReloadableVerifier(publicKeyPath, aesKeyPath, licensePath string, opts ...Option) (*ReloadableVerifier, error) {
+	r := &ReloadableVerifier{
+		publicKeyPath: publicKeyPath,
+		aesKeyPath:    aesKeyPath,
+		licensePath:   licensePath,
+		opts:          opts,
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新读取密钥和许可证文件，验证通过后替换当前的验证器和许可证
+// 验证失败时保留原来的状态并返回错误
+func (r *ReloadableVerifier) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files := r.stamps()
+
+	verifier, err := NewVerifierFromFiles(r.publicKeyPath, r.aesKeyPath, r.opts...)
+	if err != nil {
+		return err
+	}
+
+	licenseData, err := os.ReadFile(r.licensePath)
+	if err != nil {
+		return fmt.Errorf("failed to read license file: %v", err)
+	}
+
+	// 公钥不变时沿用运行期间加载的吊销列表
+	if old := r.state.Load(); old != nil && old.verifier.publicKey.Equal(verifier.publicKey) {
+		old.verifier.mu.RLock()
+		list, digest := old.verifier.revocations, old.verifier.revocationDigest
+		old.verifier.mu.RUnlock()
+
+		// 摘要与列表一起沿用，之后重新加载同一份吊销列表时不会被当作序号相同但内容不同的列表
+		if list != nil {
+			verifier.mu.Lock()
+			if verifier.revocations == nil || verifier.revocations.Sequence < list.Sequence {
+				verifier.revocations, verifier.revocationDigest = list, digest
+			}
+			verifier.mu.Unlock()
+		}
+	}
+
+	result, err := verifier.Verify(licenseData)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return result.Err
+	}
+
+	r.state.Store(&reloadState{
+		verifier:    verifier,
+		licenseData: licenseData,
+		result:      result,
+		files:       files,
+	})
+	return nil
+}
+
+// Verifier 返回当前的验证器
+func (r *ReloadableVerifier) Verifier() *Verifier {
+	return r.state.Load().verifier
+}
+
+// License 返回当前加载的许可证
+func (r *ReloadableVerifier) License() *License {
+	return r.state.Load().result.License
+}
+
+// Verify 使用当前的验证器重新验证当前的许可证（例如检查是否已过期）
+func (r *ReloadableVerifier) Verify() (*VerificationResult, error) {
+	state := r.state.Load()
+	return state.verifier.Verify(state.licenseData)
+}
+
+// Watch 每隔 interval 检查密钥和许可证文件是否变化，变化时调用 Reload，直到 ctx 取消
+// onReload 不为 nil 时在每次重新加载后调用，result 为加载时的验证结果，失败时 err 不为 nil 且 result 为仍在使用的旧结果；
+// 需要随时间更新的结果请使用 NewWatcher 创建的监视器
+func (r *ReloadableVerifier) Watch(ctx context.Context, interval time.Duration, onReload func(result *VerificationResult, err error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if r.stamps() == r.state.Load().files {
+			continue
+		}
+
+		err := r.Reload()
+		if err != nil {
+			// 记录失败时的文件状态，文件再次变化前不重复加载
+			r.mu.Lock()
+			state := *r.state.Load()
+			state.files = r.stamps()
+			r.state.Store(&state)
+			r.mu.Unlock()
+		}
+
+		if onReload != nil {
+			onReload(r.state.Load().result, err)
+		}
+	}
+}
+
+// stamps 返回密钥和许可证文件的当前状态
+func (r *ReloadableVerifier) stamps() [3]fileStamp {
+	var stamps [3]fileStamp
+	for i, path := range []string{r.publicKeyPath, r.aesKeyPath, r.licensePath} {
+		if info, err := os.Stat(path); err == nil {
+			stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
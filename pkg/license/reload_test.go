@@ -0,0 +1,209 @@
+package license_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// writeKeys 将生成器的公钥和AES密钥写入文件
+func writeKeys(t *testing.T, generator *license.Generator, publicKeyPath, aesKeyPath string) {
+	t.Helper()
+
+	publicKeyPEM, err := generator.GetPublicKeyPEM()
+	if err != nil {
+		t.Fatalf("GetPublicKeyPEM() error = %v", err)
+	}
+	if err := os.WriteFile(publicKeyPath, publicKeyPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(aesKeyPath, []byte(crypto.EncodeBase64(generator.GetAESKey())), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadableVerifier(t *testing.T) {
+	dir := t.TempDir()
+	publicKeyPath := filepath.Join(dir, "public.pem")
+	aesKeyPath := filepath.Join(dir, "aes.key")
+	licensePath := filepath.Join(dir, "license.lic")
+
+	generator, _, _, _ := newTestSetup(t)
+	writeKeys(t, generator, publicKeyPath, aesKeyPath)
+	os.WriteFile(licensePath, issue(t, generator, &license.GenerateOptions{CustomerName: "Original"}), 0644)
+
+	clock := license.WithTime(time.Now().Add(time.Minute))
+	reloadable, err := license.NewReloadableVerifier(publicKeyPath, aesKeyPath, licensePath, clock)
+	if err != nil {
+		t.Fatalf("NewReloadableVerifier() error = %v", err)
+	}
+
+	// 并发读取的同时重新加载
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if result, _ := reloadable.Verify(); !result.Valid {
+					t.Errorf("Verify() invalid during reload: %s", result.Error)
+				}
+			}
+		}()
+	}
+
+	os.WriteFile(licensePath, issue(t, generator, &license.GenerateOptions{CustomerName: "Renewed"}), 0644)
+	if err := reloadable.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	wg.Wait()
+	if got := reloadable.License().CustomerName; got != "Renewed" {
+		t.Errorf("License().CustomerName = %q, want Renewed", got)
+	}
+
+	// 无效的许可证不会替换当前许可证
+	os.WriteFile(licensePath, []byte("not a license"), 0644)
+	if err := reloadable.Reload(); err == nil {
+		t.Error("Reload() should reject an invalid license")
+	}
+	if got := reloadable.License().CustomerName; got != "Renewed" {
+		t.Errorf("License().CustomerName = %q after failed reload, want Renewed", got)
+	}
+
+	// 更换密钥和许可证
+	rotated, _, _, _ := newTestSetup(t)
+	writeKeys(t, rotated, publicKeyPath, aesKeyPath)
+	os.WriteFile(licensePath, issue(t, rotated, &license.GenerateOptions{CustomerName: "Rotated"}), 0644)
+	if err := reloadable.Reload(); err != nil {
+		t.Fatalf("Reload() with rotated keys error = %v", err)
+	}
+	if got := reloadable.License().CustomerName; got != "Rotated" {
+		t.Errorf("License().CustomerName = %q, want Rotated", got)
+	}
+}
+
+func TestReloadableVerifierWatch(t *testing.T) {
+	dir := t.TempDir()
+	publicKeyPath := filepath.Join(dir, "public.pem")
+	aesKeyPath := filepath.Join(dir, "aes.key")
+	licensePath := filepath.Join(dir, "license.lic")
+
+	generator, _, _, _ := newTestSetup(t)
+	writeKeys(t, generator, publicKeyPath, aesKeyPath)
+	os.WriteFile(licensePath, issue(t, generator, &license.GenerateOptions{}), 0644)
+
+	reloadable, err := license.NewReloadableVerifier(publicKeyPath, aesKeyPath, licensePath,
+		license.WithTime(time.Now().Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("NewReloadableVerifier() error = %v", err)
+	}
+
+	reloaded := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloadable.Watch(ctx, 10*time.Millisecond, func(_ *license.VerificationResult, err error) { reloaded <- err })
+
+	os.WriteFile(licensePath, issue(t, generator, &license.GenerateOptions{CustomerName: "Renewed"}), 0644)
+
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatalf("Watch() reload error = %v", err)
+		}
+		if got := reloadable.License().CustomerName; got != "Renewed" {
+			t.Errorf("License().CustomerName = %q, want Renewed", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() did not reload the changed license file")
+	}
+}
+
+func TestReloadKeepsRevocationList(t *testing.T) {
+	dir := t.TempDir()
+	publicKeyPath := filepath.Join(dir, "public.pem")
+	aesKeyPath := filepath.Join(dir, "aes.key")
+	licensePath := filepath.Join(dir, "license.lic")
+
+	generator, _, _, _ := newTestSetup(t)
+	writeKeys(t, generator, publicKeyPath, aesKeyPath)
+	os.WriteFile(licensePath, issue(t, generator, &license.GenerateOptions{}), 0644)
+
+	reloadable, err := license.NewReloadableVerifier(publicKeyPath, aesKeyPath, licensePath,
+		license.WithTime(time.Now().Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("NewReloadableVerifier() error = %v", err)
+	}
+
+	crl, _, err := generator.Revoke(nil, "some-other-license", "")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if err := reloadable.Verifier().LoadRevocationList(crl); err != nil {
+		t.Fatalf("LoadRevocationList() error = %v", err)
+	}
+
+	if err := reloadable.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if reloadable.Verifier().RevocationList() == nil {
+		t.Fatal("Reload() dropped the revocation list")
+	}
+
+	// 重新加载后再次加载同一份吊销列表
+	if err := reloadable.Verifier().LoadRevocationList(crl); err != nil {
+		t.Errorf("LoadRevocationList() with the same list after Reload() error = %v", err)
+	}
+}
+
+func TestReloadableVerifierWatcher(t *testing.T) {
+	dir := t.TempDir()
+	publicKeyPath := filepath.Join(dir, "public.pem")
+	aesKeyPath := filepath.Join(dir, "aes.key")
+	licensePath := filepath.Join(dir, "license.lic")
+
+	generator, _, clock, _ := newTestSetup(t)
+	writeKeys(t, generator, publicKeyPath, aesKeyPath)
+	os.WriteFile(licensePath, issue(t, generator, &license.GenerateOptions{Duration: 24 * time.Hour}), 0644)
+
+	reloadable, err := license.NewReloadableVerifier(publicKeyPath, aesKeyPath, licensePath, license.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewReloadableVerifier() error = %v", err)
+	}
+
+	var replaced int
+	watcher := reloadable.NewWatcher(license.OnReplaced(func(old, new *license.VerificationResult) { replaced++ }))
+	if result := watcher.Check(); !result.Valid {
+		t.Fatalf("Check() invalid: %s", result.Error)
+	}
+
+	// 更换密钥和许可证后，监视器使用重新加载的验证器
+	rotated, _, _, _ := newTestSetup(t)
+	writeKeys(t, rotated, publicKeyPath, aesKeyPath)
+	os.WriteFile(licensePath, issue(t, rotated, &license.GenerateOptions{CustomerName: "Rotated", Duration: 24 * time.Hour}), 0644)
+	if err := reloadable.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	result := watcher.Check()
+	if !result.Valid || result.License.CustomerName != "Rotated" {
+		t.Fatalf("Check() after Reload() = %+v, want the rotated license", result)
+	}
+	if replaced != 1 {
+		t.Errorf("replaced = %d, want 1", replaced)
+	}
+
+	// 加载时有效的许可证随时间过期
+	clock.Advance(48 * time.Hour)
+	if result := watcher.Check(); result.Valid {
+		t.Error("Check() should report the expired license")
+	}
+	if watcher.Result().Valid {
+		t.Error("Result() should report the expired license")
+	}
+}
@@ -0,0 +1,90 @@
+package license
+
+// CheckStatus 检查项状态
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "pass" // 检查通过
+	CheckFailed  CheckStatus = "fail" // 检查失败
+	CheckSkipped CheckStatus = "skip" // 不适用，未检查
+)
+
+// 检查项名称
+const (
+	CheckFormat     = "format"     // 许可证文件格式及版本
+	CheckSignature  = "signature"  // 数字签名
+	CheckDecrypt    = "decrypt"    // 解密及解析许可证数据
+	CheckClock      = "clock"      // 系统时钟回拨检测
+	CheckTimeToken  = "time_token" // 可信时间令牌
+	CheckNotBefore  = "not_before" // 签发时间
+	CheckExpiry     = "expiry"     // 过期时间
+	CheckProduct    = "product"    // 产品授权
+	CheckRevocation = "revocation" // 吊销列表
+	CheckLease      = "lease"      // 签到租约
+	CheckIntegrity  = "integrity"  // 可执行文件完整性
+	CheckMachine    = "machine"    // 机器绑定（整体）
+)
+
+// CheckResult 单个检查项的结果
+type CheckResult struct {
+	Name     string      `json:"name"`               // 检查项名称
+	Status   CheckStatus `json:"status"`             // 检查状态
+	Expected string      `json:"expected,omitempty"` // 期望值
+	Actual   string      `json:"actual,omitempty"`   // 实际值
+	Message  string      `json:"message,omitempty"`  // 说明
+}
+
+// Check 返回指定名称的检查结果，不存在时返回 nil
+func (r *VerificationResult) Check(name string) *CheckResult {
+	for i := range r.Checks {
+		if r.Checks[i].Name == name {
+			return &r.Checks[i]
+		}
+	}
+	return nil
+}
+
+// FailedChecks 返回所有失败的检查项
+func (r *VerificationResult) FailedChecks() []CheckResult {
+	var failed []CheckResult
+	for _, c := range r.Checks {
+		if c.Status == CheckFailed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// pass 记录通过的检查项
+func (r *VerificationResult) pass(name, expected, actual string) {
+	r.Checks = append(r.Checks, CheckResult{
+		Name:     name,
+		Status:   CheckPassed,
+		Expected: expected,
+		Actual:   actual,
+	})
+}
+
+// fail 记录失败的检查项，第一个失败的检查项作为整体错误
+func (r *VerificationResult) fail(name, expected, actual string, errType error, message string) {
+	r.Checks = append(r.Checks, CheckResult{
+		Name:     name,
+		Status:   CheckFailed,
+		Expected: expected,
+		Actual:   actual,
+		Message:  message,
+	})
+	if r.Err == nil {
+		r.Err = &VerificationError{Check: name, Err: errType, Message: message}
+		r.Error = message
+	}
+}
+
+// skip 记录未检查的检查项
+func (r *VerificationResult) skip(name, message string) {
+	r.Checks = append(r.Checks, CheckResult{
+		Name:    name,
+		Status:  CheckSkipped,
+		Message: message,
+	})
+}
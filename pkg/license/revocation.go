@@ -0,0 +1,204 @@
+package license
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// DocumentTypeRevocationList 许可证吊销列表的文档类型
+const DocumentTypeRevocationList = "revocation-list"
+
+// Revocation 吊销记录
+type Revocation struct {
+	LicenseID string    `json:"license_id"`       // 被吊销的许可证ID
+	Reason    string    `json:"reason,omitempty"` // 吊销原因
+	RevokedAt time.Time `json:"revoked_at"`       // 吊销时间
+}
+
+// RevocationList 许可证吊销列表
+// 每次更新序号递增，验证器拒绝加载序号比已加载列表更小的列表，防止用旧列表替换新列表；
+// 配置了实现 RevocationStore 的时间存储时，已加载的最高序号在进程重启后仍然有效
+type RevocationList struct {
+	Sequence uint64       `json:"sequence"`  // 序号
+	IssuedAt time.Time    `json:"issued_at"` // 签发时间
+	Entries  []Revocation `json:"entries"`   // 吊销记录
+}
+
+// RevocationStore 记录已加载的吊销列表的最高序号和内容摘要，FileTimeStore 实现了该接口
+type RevocationStore interface {
+	// LoadRevocation 返回记录的序号和摘要，尚未记录时摘要为空
+	LoadRevocation() (sequence uint64, digest string, err error)
+	// SaveRevocation 记录序号和摘要
+	SaveRevocation(sequence uint64, digest string) error
+}
+
+// Lookup 查找许可证的吊销记录，未吊销时返回 nil
+func (l *RevocationList) Lookup(licenseID string) *Revocation {
+	for i := range l.Entries {
+		if l.Entries[i].LicenseID == licenseID {
+			return &l.Entries[i]
+		}
+	}
+	return nil
+}
+
+// SignRevocationList 签名吊销列表
+func (g *Generator) SignRevocationList(list *RevocationList) ([]byte, error) {
+	return signDocument(DocumentTypeRevocationList, list, g.privateKey)
+}
+
+// Revoke 向吊销列表中添加一条记录，序号加一并重新签名
+// listData 为空时创建新的吊销列表，否则必须是由同一私钥签名的吊销列表
+func (g *Generator) Revoke(listData []byte, licenseID, reason string) ([]byte, *RevocationList, error) {
+	if licenseID == "" {
+		return nil, nil, fmt.Errorf("license ID cannot be empty")
+	}
+
+	list := &RevocationList{}
+	if len(listData) > 0 {
+		if err := openDocument(listData, DocumentTypeRevocationList, g.GetPublicKey(), list); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if list.Lookup(licenseID) != nil {
+		return nil, nil, fmt.Errorf("license %s is already revoked", licenseID)
+	}
+
+	now := time.Now().UTC()
+	list.Sequence++
+	list.IssuedAt = now
+	list.Entries = append(list.Entries, Revocation{
+		LicenseID: licenseID,
+		Reason:    reason,
+		RevokedAt: now,
+	})
+
+	data, err := g.SignRevocationList(list)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g.logIssued("license revoked",
+		slog.String("license_id", licenseID),
+		slog.String("reason", reason),
+		slog.Uint64("sequence", list.Sequence))
+	return data, list, nil
+}
+
+// ParseRevocationList 校验签名并解析吊销列表
+func (v *Verifier) ParseRevocationList(data []byte) (*RevocationList, error) {
+	var list RevocationList
+	if err := openDocument(data, DocumentTypeRevocationList, v.publicKey, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// LoadRevocationList 加载吊销列表，之后的验证会拒绝被吊销的许可证
+// 序号小于已加载列表，或序号相同但内容不同的吊销列表会被拒绝，可以在运行期间调用以更新列表；
+// 时间存储实现了 RevocationStore 时，同时与其中记录的最高序号比较并更新记录
+func (v *Verifier) LoadRevocationList(data []byte) error {
+	list, err := v.ParseRevocationList(data)
+	if err != nil {
+		return err
+	}
+	digest, err := revocationDigest(list)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.revocations != nil {
+		if err := checkRevocationSequence(list, digest, v.revocations.Sequence, v.revocationDigest); err != nil {
+			return err
+		}
+	}
+
+	if store, ok := v.timeStore.(RevocationStore); ok {
+		sequence, seen, err := store.LoadRevocation()
+		if err != nil {
+			return fmt.Errorf("failed to load revocation state: %v", err)
+		}
+		if seen != "" {
+			if err := checkRevocationSequence(list, digest, sequence, seen); err != nil {
+				return err
+			}
+		}
+		if seen != digest {
+			if err := store.SaveRevocation(list.Sequence, digest); err != nil {
+				return fmt.Errorf("failed to save revocation state: %v", err)
+			}
+		}
+	}
+
+	v.revocations = list
+	v.revocationDigest = digest
+	return nil
+}
+
+// checkRevocationSequence 检查吊销列表是否比已加载的列表旧
+func checkRevocationSequence(list *RevocationList, digest string, sequence uint64, seen string) error {
+	switch {
+	case list.Sequence < sequence:
+		return fmt.Errorf("%w: sequence %d is older than the loaded sequence %d",
+			ErrStaleRevocationList, list.Sequence, sequence)
+	case list.Sequence == sequence && digest != seen:
+		return fmt.Errorf("%w: a different list with sequence %d has already been loaded",
+			ErrStaleRevocationList, list.Sequence)
+	}
+	return nil
+}
+
+// revocationDigest 计算吊销列表内容的摘要
+func revocationDigest(list *RevocationList) (string, error) {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal revocation list: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// LoadRevocationListFile 从文件加载吊销列表
+func (v *Verifier) LoadRevocationListFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read revocation list: %v", err)
+	}
+	return v.LoadRevocationList(data)
+}
+
+// RevocationList 返回当前加载的吊销列表，未加载时返回 nil
+func (v *Verifier) RevocationList() *RevocationList {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.revocations
+}
+
+// checkRevocation 检查许可证是否已被吊销
+func (v *Verifier) checkRevocation(result *VerificationResult, license *License) {
+	list := v.RevocationList()
+	if list == nil {
+		result.skip(CheckRevocation, "no revocation list loaded")
+		return
+	}
+
+	sequence := fmt.Sprintf("not in revocation list #%d", list.Sequence)
+	if r := list.Lookup(license.ID); r != nil {
+		message := fmt.Sprintf("license has been revoked at %s", r.RevokedAt.Format(time.RFC3339))
+		if r.Reason != "" {
+			message += ": " + r.Reason
+		}
+		result.fail(CheckRevocation, sequence, "revoked", ErrRevoked, message)
+		return
+	}
+	result.pass(CheckRevocation, sequence, "not revoked")
+}
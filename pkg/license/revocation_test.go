@@ -0,0 +1,115 @@
+package license_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func TestRevocationList(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+
+	lic, err := generator.Generate(&license.GenerateOptions{})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	data, err := generator.Encode(lic)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	first, _, err := generator.Revoke(nil, "some-other-license", "")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	second, list, err := generator.Revoke(first, lic.ID, "leaked")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if list.Sequence != 2 || len(list.Entries) != 2 {
+		t.Fatalf("Revoke() list = %+v, want sequence 2 with 2 entries", list)
+	}
+
+	if err := verifier.LoadRevocationList(first); err != nil {
+		t.Fatalf("LoadRevocationList() error = %v", err)
+	}
+	result, _ := verifier.Verify(data)
+	if !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+
+	if err := verifier.LoadRevocationList(second); err != nil {
+		t.Fatalf("LoadRevocationList() error = %v", err)
+	}
+	result, _ = verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrRevoked) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrRevoked)
+	}
+
+	// 不能用旧列表替换新列表
+	if err := verifier.LoadRevocationList(first); !errors.Is(err, license.ErrStaleRevocationList) {
+		t.Errorf("LoadRevocationList() with older list error = %v, want %v", err, license.ErrStaleRevocationList)
+	}
+
+	if _, _, err := generator.Revoke(second, lic.ID, "again"); err == nil {
+		t.Error("Revoke() should reject an already revoked license")
+	}
+}
+
+func TestRevocationSequencePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	generator, verifier, _, _ := newTestSetup(t, license.WithTimeStore(license.NewFileTimeStore(path, nil), time.Hour))
+
+	first, _, err := generator.Revoke(nil, "license-1", "")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	second, _, err := generator.Revoke(first, "license-2", "")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	forked, _, err := generator.Revoke(first, "license-3", "")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	if err := verifier.LoadRevocationList(second); err != nil {
+		t.Fatalf("LoadRevocationList() error = %v", err)
+	}
+	if err := verifier.LoadRevocationList(forked); !errors.Is(err, license.ErrStaleRevocationList) {
+		t.Errorf("LoadRevocationList() with same sequence and different contents error = %v, want %v", err, license.ErrStaleRevocationList)
+	}
+
+	// 重启后（新的验证器使用同一个时间存储）仍然拒绝旧的吊销列表
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	restarted := func() *license.Verifier {
+		v, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(),
+			license.WithTimeStore(license.NewFileTimeStore(path, nil), time.Hour))
+		if err != nil {
+			t.Fatalf("NewVerifier() error = %v", err)
+		}
+		return v
+	}
+
+	if err := restarted().LoadRevocationList(first); !errors.Is(err, license.ErrStaleRevocationList) {
+		t.Errorf("LoadRevocationList() with older list after restart error = %v, want %v", err, license.ErrStaleRevocationList)
+	}
+	if err := restarted().LoadRevocationList(forked); !errors.Is(err, license.ErrStaleRevocationList) {
+		t.Errorf("LoadRevocationList() with forked list after restart error = %v, want %v", err, license.ErrStaleRevocationList)
+	}
+	if err := restarted().LoadRevocationList(second); err != nil {
+		t.Errorf("LoadRevocationList() with the same list after restart error = %v", err)
+	}
+
+	// 记录验证时间不影响已记录的吊销列表序号
+	result, _ := verifier.Verify(issue(t, generator, &license.GenerateOptions{}))
+	if !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+	if err := restarted().LoadRevocationList(first); !errors.Is(err, license.ErrStaleRevocationList) {
+		t.Errorf("LoadRevocationList() with older list after saving the time error = %v, want %v", err, license.ErrStaleRevocationList)
+	}
+}
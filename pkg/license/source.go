@@ -0,0 +1,229 @@
+package license
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+)
+
+// LicenseSource 许可证（或密钥）的来源
+type LicenseSource interface {
+	// Load 读取内容，来源不存在时返回包装了 ErrSourceNotFound 的错误
+	Load() ([]byte, error)
+	// String 返回来源的描述，用于错误信息
+	String() string
+}
+
+// FileSource 从文件读取，路径按原样使用，不展开 ~ 和环境变量
+func FileSource(path string) LicenseSource {
+	return fileSource(path)
+}
+
+type fileSource string
+
+func (s fileSource) Load() ([]byte, error) {
+	data, err := os.ReadFile(string(s))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSourceNotFound, s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", s, err)
+	}
+	return data, nil
+}
+
+func (s fileSource) String() string {
+	return "file " + string(s)
+}
+
+// ReaderSource 从 io.Reader 读取（如标准输入），第一次读取后缓存内容
+func ReaderSource(r io.Reader, name string) LicenseSource {
+	return &readerSource{r: r, name: name}
+}
+
+type readerSource struct {
+	r    io.Reader
+	name string
+
+	once sync.Once
+	data []byte
+	err  error
+}
+
+func (s *readerSource) Load() ([]byte, error) {
+	s.once.Do(func() {
+		s.data, s.err = io.ReadAll(s.r)
+		if s.err != nil {
+			s.err = fmt.Errorf("failed to read %s: %v", s.name, s.err)
+		}
+	})
+	return s.data, s.err
+}
+
+func (s *readerSource) String() string {
+	return s.name
+}
+
+// FSSource 从 fs.FS 读取，可用于 embed.FS 或测试用的 fstest.MapFS
+func FSSource(fsys fs.FS, name string) LicenseSource {
+	return &fsSource{fsys: fsys, name: name}
+}
+
+type fsSource struct {
+	fsys fs.FS
+	name string
+}
+
+func (s *fsSource) Load() ([]byte, error) {
+	data, err := fs.ReadFile(s.fsys, s.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: embedded %s", ErrSourceNotFound, s.name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded %s: %v", s.name, err)
+	}
+	return data, nil
+}
+
+func (s *fsSource) String() string {
+	return "embedded " + s.name
+}
+
+// EnvSource 从环境变量读取原始内容
+func EnvSource(name string) LicenseSource {
+	return envSource{name: name}
+}
+
+// Base64EnvSource 从环境变量读取 Base64 编码的内容，适合不便保存多行文本的场景
+func Base64EnvSource(name string) LicenseSource {
+	return envSource{name: name, base64: true}
+}
+
+type envSource struct {
+	name   string
+	base64 bool
+}
+
+func (s envSource) Load() ([]byte, error) {
+	value, ok := os.LookupEnv(s.name)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("%w: environment variable %s is not set", ErrSourceNotFound, s.name)
+	}
+	if !s.base64 {
+		return []byte(value), nil
+	}
+
+	data, err := crypto.DecodeBase64(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode environment variable %s: %v", s.name, err)
+	}
+	return data, nil
+}
+
+func (s envSource) String() string {
+	return "environment variable " + s.name
+}
+
+// FirstOf 依次尝试多个来源，返回第一个存在的来源的内容
+// 来源存在但读取失败时直接返回错误，不再尝试后面的来源
+func FirstOf(sources ...LicenseSource) LicenseSource {
+	return firstOf(sources)
+}
+
+type firstOf []LicenseSource
+
+func (s firstOf) Load() ([]byte, error) {
+	for _, source := range s {
+		data, err := source.Load()
+		if errors.Is(err, ErrSourceNotFound) {
+			continue
+		}
+		return data, err
+	}
+	return nil, fmt.Errorf("%w (tried %s)", ErrSourceNotFound, s)
+}
+
+func (s firstOf) String() string {
+	names := make([]string, len(s))
+	for i, source := range s {
+		names[i] = source.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// SearchPath 依次查找多个文件，路径中的 ~ 和环境变量会被展开
+func SearchPath(paths ...string) LicenseSource {
+	sources := make([]LicenseSource, len(paths))
+	for i, path := range paths {
+		sources[i] = FileSource(expandPath(path))
+	}
+	return FirstOf(sources...)
+}
+
+// DefaultSearchPath 返回应用的默认许可证查找路径：
+// ./license.lic、~/.config/<app>/license.lic、/etc/<app>/license.lic
+func DefaultSearchPath(app string) []string {
+	return []string{
+		"license.lic",
+		filepath.Join("~", ".config", app, "license.lic"),
+		filepath.Join("/etc", app, "license.lic"),
+	}
+}
+
+// expandPath 展开路径开头的 ~ 和路径中的环境变量
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// NewVerifierFromSources 从来源读取公钥和AES密钥（Base64编码，与密钥文件格式相同）创建验证器
+func NewVerifierFromSources(publicKey, aesKey LicenseSource, opts ...Option) (*Verifier, error) {
+	publicKeyPEM, err := publicKey.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public key: %v", err)
+	}
+
+	aesKeyEncoded, err := aesKey.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AES key: %v", err)
+	}
+
+	aesKeyBytes, err := crypto.DecodeBase64(strings.TrimSpace(string(aesKeyEncoded)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode AES key: %v", err)
+	}
+
+	return NewVerifier(publicKeyPEM, aesKeyBytes, opts...)
+}
+
+// VerifySource 从来源读取并验证许可证
+func (v *Verifier) VerifySource(source LicenseSource) (*VerificationResult, error) {
+	return v.VerifySourceContext(context.Background(), source)
+}
+
+// VerifySourceContext 从来源读取许可证，并在 ctx 的限制内验证
+func (v *Verifier) VerifySourceContext(ctx context.Context, source LicenseSource) (*VerificationResult, error) {
+	fileData, err := source.Load()
+	if err != nil {
+		result := &VerificationResult{
+			VerifiedAt: v.clock.Now(),
+		}
+		result.fail(CheckFormat, "", "", ErrReadLicense, fmt.Sprintf("failed to load license: %v", err))
+		v.report(ctx, result)
+		return result, nil
+	}
+	return v.VerifyContext(ctx, fileData)
+}
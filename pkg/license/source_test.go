@@ -0,0 +1,139 @@
+package license_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func TestLicenseSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "license.lic")
+	if err := os.WriteFile(path, []byte("file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_LICENSE", "env")
+	t.Setenv("TEST_LICENSE_B64", crypto.EncodeBase64([]byte("base64")))
+	t.Setenv("TEST_LICENSE_BAD", "!!!")
+
+	fsys := fstest.MapFS{"license.lic": {Data: []byte("fs")}}
+
+	tests := []struct {
+		name    string
+		source  license.LicenseSource
+		want    string
+		wantErr error
+	}{
+		{"file", license.FileSource(path), "file", nil},
+		{"missing file", license.FileSource(filepath.Join(dir, "missing.lic")), "", license.ErrSourceNotFound},
+		{"reader", license.ReaderSource(strings.NewReader("reader"), "stdin"), "reader", nil},
+		{"fs", license.FSSource(fsys, "license.lic"), "fs", nil},
+		{"missing fs", license.FSSource(fsys, "other.lic"), "", license.ErrSourceNotFound},
+		{"env", license.EnvSource("TEST_LICENSE"), "env", nil},
+		{"unset env", license.EnvSource("TEST_LICENSE_UNSET"), "", license.ErrSourceNotFound},
+		{"base64 env", license.Base64EnvSource("TEST_LICENSE_B64"), "base64", nil},
+		{"search path", license.SearchPath(filepath.Join(dir, "missing.lic"), path), "file", nil},
+		{"first of", license.FirstOf(license.EnvSource("TEST_LICENSE_UNSET"), license.EnvSource("TEST_LICENSE")), "env", nil},
+		{"none found", license.FirstOf(license.EnvSource("TEST_LICENSE_UNSET")), "", license.ErrSourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.source.Load()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Load() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+
+	// 来源存在但内容无效时不继续尝试后面的来源
+	_, err := license.FirstOf(license.Base64EnvSource("TEST_LICENSE_BAD"), license.EnvSource("TEST_LICENSE")).Load()
+	if err == nil || errors.Is(err, license.ErrSourceNotFound) {
+		t.Errorf("FirstOf() with invalid base64 error = %v, want decode error", err)
+	}
+}
+
+func TestSearchPathExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	configDir := filepath.Join(home, ".config", "myapp")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "license.lic"), []byte("home"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := license.SearchPath(license.DefaultSearchPath("myapp")[1:]...).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if string(data) != "home" {
+		t.Errorf("Load() = %q, want %q", data, "home")
+	}
+}
+
+func TestFileSourceKeepsPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_LICENSE_NAME", "other")
+
+	// 显式指定的文件路径不展开环境变量
+	path := filepath.Join(dir, "$TEST_LICENSE_NAME.lic")
+	if err := os.WriteFile(path, []byte("literal"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other.lic"), []byte("expanded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := license.FileSource(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if string(data) != "literal" {
+		t.Errorf("Load() = %q, want %q", data, "literal")
+	}
+
+	// 查找路径中的环境变量会被展开
+	data, err = license.SearchPath(path).Load()
+	if err != nil {
+		t.Fatalf("SearchPath().Load() error = %v", err)
+	}
+	if string(data) != "expanded" {
+		t.Errorf("SearchPath().Load() = %q, want %q", data, "expanded")
+	}
+}
+
+func TestVerifySource(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{Duration: 24 * time.Hour})
+	t.Setenv("TEST_LICENSE_B64", crypto.EncodeBase64(data))
+
+	result, err := verifier.VerifySource(license.Base64EnvSource("TEST_LICENSE_B64"))
+	if err != nil {
+		t.Fatalf("VerifySource() error = %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("VerifySource() invalid: %s", result.Error)
+	}
+
+	result, err = verifier.VerifySource(license.EnvSource("TEST_LICENSE_UNSET"))
+	if err != nil {
+		t.Fatalf("VerifySource() error = %v", err)
+	}
+	if result.Valid || !errors.Is(result.Err, license.ErrReadLicense) {
+		t.Errorf("VerifySource() missing source Err = %v, want ErrReadLicense", result.Err)
+	}
+}
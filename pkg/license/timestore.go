@@ -0,0 +1,186 @@
+package license
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/crypto"
+)
+
+// DefaultClockTolerance 默认允许的时钟回拨容差（例如NTP校时造成的小幅回拨）
+const DefaultClockTolerance = 10 * time.Minute
+
+// TimeStore 记录最后一次成功验证时间的存储，用于检测系统时钟回拨
+type TimeStore interface {
+	// Load 返回记录的时间，尚未记录时返回零值
+	Load() (time.Time, error)
+	// Save 记录时间
+	Save(t time.Time) error
+}
+
+// FileTimeStore 基于文件的时间存储，文件内容使用HMAC防止篡改
+// 同时实现了 RevocationStore，记录已加载的吊销列表的最高序号
+// 注意：删除文件可以清除记录，它只用于提高篡改时钟的成本
+type FileTimeStore struct {
+	mu   sync.Mutex
+	path string
+	key  []byte
+}
+
+// timeStoreFile 时间存储文件格式
+type timeStoreFile struct {
+	LastSeen    string `json:"last_seen,omitempty"`    // RFC 3339 格式的时间
+	CRLSequence uint64 `json:"crl_sequence,omitempty"` // 已加载的吊销列表的最高序号
+	CRLDigest   string `json:"crl_digest,omitempty"`   // 该吊销列表内容的摘要
+	MAC         string `json:"mac"`                    // HMAC-SHA256（Base64编码）
+}
+
+// signedData 返回HMAC覆盖的数据，未记录吊销列表时只包含时间，与旧版本写入的文件兼容
+func (f *timeStoreFile) signedData() []byte {
+	if f.CRLDigest == "" {
+		return []byte(f.LastSeen)
+	}
+	return []byte(fmt.Sprintf("%s\n%d\n%s", f.LastSeen, f.CRLSequence, f.CRLDigest))
+}
+
+// NewFileTimeStore 创建基于文件的时间存储
+// key 为 nil 时，作为验证器选项使用时会从验证器的AES密钥派生
+func NewFileTimeStore(path string, key []byte) *FileTimeStore {
+	return &FileTimeStore{
+		path: path,
+		key:  key,
+	}
+}
+
+// Path 返回存储文件路径
+func (s *FileTimeStore) Path() string {
+	return s.path
+}
+
+// Load 读取并校验记录的时间
+func (s *FileTimeStore) Load() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.read()
+	if err != nil || file.LastSeen == "" {
+		return time.Time{}, err
+	}
+
+	lastSeen, err := time.Parse(time.RFC3339Nano, file.LastSeen)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse time store: %v", err)
+	}
+
+	return lastSeen, nil
+}
+
+// Save 记录时间，保留已记录的吊销列表序号
+// 无法校验的记录会被覆盖，与删除文件的效果相同
+func (s *FileTimeStore) Save(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.key) == 0 {
+		return fmt.Errorf("time store key is not set")
+	}
+
+	file, err := s.read()
+	if err != nil {
+		file = &timeStoreFile{}
+	}
+	file.LastSeen = t.UTC().Format(time.RFC3339Nano)
+	return s.write(file)
+}
+
+// LoadRevocation 读取记录的吊销列表序号和摘要，尚未记录时摘要为空
+func (s *FileTimeStore) LoadRevocation() (uint64, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.read()
+	if err != nil {
+		return 0, "", err
+	}
+	return file.CRLSequence, file.CRLDigest, nil
+}
+
+// SaveRevocation 记录吊销列表序号和摘要，保留已记录的时间
+func (s *FileTimeStore) SaveRevocation(sequence uint64, digest string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+	file.CRLSequence = sequence
+	file.CRLDigest = digest
+	return s.write(file)
+}
+
+// read 读取并校验存储文件，文件不存在时返回空记录
+func (s *FileTimeStore) read() (*timeStoreFile, error) {
+	if len(s.key) == 0 {
+		return nil, fmt.Errorf("time store key is not set")
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &timeStoreFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read time store: %v", err)
+	}
+
+	var file timeStoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse time store: %v", err)
+	}
+
+	mac, err := crypto.DecodeBase64(file.MAC)
+	if err != nil || !crypto.VerifyHMAC(file.signedData(), mac, s.key) {
+		return nil, fmt.Errorf("time store has been tampered with")
+	}
+
+	return &file, nil
+}
+
+// write 计算HMAC并写入存储文件，先写入临时文件再重命名，避免写入中断导致文件损坏
+func (s *FileTimeStore) write(file *timeStoreFile) error {
+	file.MAC = crypto.EncodeBase64(crypto.ComputeHMAC(file.signedData(), s.key))
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to marshal time store: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create time store directory: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write time store: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write time store: %v", err)
+	}
+
+	return nil
+}
+
+// bindKey 未设置密钥时从验证器的AES密钥派生
+func (s *FileTimeStore) bindKey(aesKey []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.key) == 0 {
+		s.key = crypto.DeriveKey(aesKey, "license-key-verify time store")
+	}
+}
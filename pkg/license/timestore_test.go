@@ -0,0 +1,115 @@
+package license_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func TestClockRollbackDetection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "last_seen.json")
+	store := license.NewFileTimeStore(path, nil)
+
+	generator, verifier, clock, _ := newTestSetup(t, license.WithTimeStore(store, time.Hour))
+	data := issue(t, generator, &license.GenerateOptions{Duration: 30 * 24 * time.Hour})
+
+	clock.Advance(10 * 24 * time.Hour)
+	result, _ := verifier.Verify(data)
+	if !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+
+	// 容差范围内的回拨仍然有效
+	clock.Advance(-30 * time.Minute)
+	result, _ = verifier.Verify(data)
+	if !result.Valid {
+		t.Errorf("Verify() within tolerance invalid: %s", result.Error)
+	}
+
+	// 超过容差的回拨被检测到
+	clock.Advance(-2 * 24 * time.Hour)
+	result, _ = verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrClockRollback) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrClockRollback)
+	}
+}
+
+func TestFixedTimeNotSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last_seen.json")
+	store := license.NewFileTimeStore(path, nil)
+
+	// 以将来的时间验证（lkverify --at）不应推进记录的时间，否则之后使用真实时钟的验证会被判定为时钟回拨
+	at := time.Now().Add(20 * 24 * time.Hour)
+	generator, verifier, _, _ := newTestSetup(t, license.WithTimeStore(store, time.Hour), license.WithTime(at))
+	data := issue(t, generator, &license.GenerateOptions{Duration: 30 * 24 * time.Hour})
+
+	lastSeen := time.Now().UTC().Truncate(time.Second)
+	if err := store.Save(lastSeen); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	result, _ := verifier.Verify(data)
+	if !result.Valid {
+		t.Fatalf("Verify() at %s invalid: %s", at, result.Error)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !got.Equal(lastSeen) {
+		t.Errorf("Load() = %s, want %s", got, lastSeen)
+	}
+}
+
+func TestFixedTimeSkipsRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last_seen.json")
+	store := license.NewFileTimeStore(path, nil)
+
+	// 以早于记录时间的日期验证（lkverify --at）不是时钟回拨
+	at := time.Now().Add(2 * 24 * time.Hour)
+	generator, verifier, _, _ := newTestSetup(t, license.WithTimeStore(store, time.Hour), license.WithTime(at))
+	data := issue(t, generator, &license.GenerateOptions{Duration: 30 * 24 * time.Hour})
+
+	if err := store.Save(time.Now().Add(10 * 24 * time.Hour)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	result, _ := verifier.Verify(data)
+	if !result.Valid {
+		t.Fatalf("Verify() at %s invalid: %s", at, result.Error)
+	}
+	if check := result.Check(license.CheckClock); check == nil || check.Status != license.CheckSkipped {
+		t.Errorf("Check(%s) = %+v, want skipped", license.CheckClock, check)
+	}
+}
+
+func TestFileTimeStoreTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last_seen.json")
+	store := license.NewFileTimeStore(path, []byte("secret"))
+
+	if err := store.Save(time.Now()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := store.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// 用另一个密钥写入的记录视为被篡改
+	other := license.NewFileTimeStore(path, []byte("other secret"))
+	if err := other.Save(time.Now().Add(-24 * time.Hour)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Error("Load() should fail for a record signed with another key")
+	}
+
+	os.Remove(path)
+	if last, err := store.Load(); err != nil || !last.IsZero() {
+		t.Errorf("Load() on missing file = %v, %v, want zero time", last, err)
+	}
+}
@@ -0,0 +1,46 @@
+package license
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DocumentTypeTimeToken 时间令牌的文档类型
+const DocumentTypeTimeToken = "time-token"
+
+// TimeToken 可信时间令牌
+// 由签发方签名，证明当前时间不早于 Time，用于离线环境下防止通过回拨时钟绕过有效期
+type TimeToken struct {
+	ID   string    `json:"id"`   // 令牌ID
+	Time time.Time `json:"time"` // 可信时间
+}
+
+// IssueTimeToken 签发时间令牌，t 为零值时使用当前时间
+func (g *Generator) IssueTimeToken(t time.Time) ([]byte, error) {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	id, err := g.generateLicenseID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %v", err)
+	}
+
+	data, err := signDocument(DocumentTypeTimeToken, &TimeToken{ID: id, Time: t.UTC()}, g.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	g.logIssued("time token issued", slog.String("token_id", id), slog.Time("time", t.UTC()))
+	return data, nil
+}
+
+// ParseTimeToken 校验签名并解析时间令牌
+func (v *Verifier) ParseTimeToken(data []byte) (*TimeToken, error) {
+	var token TimeToken
+	if err := openDocument(data, DocumentTypeTimeToken, v.publicKey, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
@@ -0,0 +1,55 @@
+package license_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func TestTimeTokenLowerBound(t *testing.T) {
+	generator, _, _, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{Duration: 24 * time.Hour})
+
+	publicKeyPEM, err := generator.GetPublicKeyPEM()
+	if err != nil {
+		t.Fatalf("GetPublicKeyPEM() error = %v", err)
+	}
+
+	// 令牌证明当前时间已经晚于许可证过期时间，回拨本地时钟无效
+	token, err := generator.IssueTimeToken(time.Now().Add(48 * time.Hour))
+	if err != nil {
+		t.Fatalf("IssueTimeToken() error = %v", err)
+	}
+
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(),
+		license.WithTime(time.Now().Add(time.Minute)),
+		license.WithTimeToken(token))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	result, _ := verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrExpired) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrExpired)
+	}
+	if c := result.Check(license.CheckTimeToken); c == nil || c.Status != license.CheckPassed {
+		t.Errorf("Verify() time token check = %+v, want passed", c)
+	}
+}
+
+func TestTimeTokenWrongKey(t *testing.T) {
+	generator, _, _, _ := newTestSetup(t)
+	other, _, _, _ := newTestSetup(t)
+
+	token, err := other.IssueTimeToken(time.Time{})
+	if err != nil {
+		t.Fatalf("IssueTimeToken() error = %v", err)
+	}
+
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+	if _, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(), license.WithTimeToken(token)); err == nil {
+		t.Error("NewVerifier() should reject a time token signed by another key")
+	}
+}
@@ -2,6 +2,8 @@ package license
 
 import (
 	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/machine"
 )
 
 // License 许可证结构体
@@ -16,6 +18,11 @@ type License struct {
 	UUID  string `json:"uuid"`  // 系统UUID
 	CPUID string `json:"cpuid"` // CPU ID
 
+	// 多机器绑定：当前机器与其中任意一项匹配即可
+	Machines    []machine.MachineInfo `json:"machines,omitempty"`     // 允许的机器列表
+	MaxMachines int                   `json:"max_machines,omitempty"` // 允许的最大机器数
+	MatchPolicy *MatchPolicy          `json:"match_policy,omitempty"` // 机器匹配策略，为空时所有组件都必须匹配
+
 	// 时间信息
 	IssuedAt  time.Time `json:"issued_at"`  // 签发时间
 	ExpiresAt time.Time `json:"expires_at"` // 过期时间
@@ -24,12 +31,57 @@ type License struct {
 	Features []string `json:"features"`  // 允许的功能列表
 	MaxUsers int      `json:"max_users"` // 最大用户数
 
+	// 产品授权（多产品许可证）
+	Products []ProductGrant `json:"products,omitempty"` // 产品授权列表
+
+	// 订阅许可证：需要定期联网签到，获取签名的租约
+	CheckIn *CheckInPolicy `json:"check_in,omitempty"` // 签到策略
+
+	// 可执行文件完整性：验证器未配置完整性清单时验证失败
+	RequireIntegrity bool `json:"require_integrity,omitempty"` // 是否要求完整性检查
+
+	// 浮动许可证：客户部署的许可证服务器用对应的私钥签名席位租约
+	LeaseKey string `json:"lease_key,omitempty"` // 租约签名公钥（PEM）
+
 	// 其他信息
 	CustomerName string                 `json:"customer_name"` // 客户名称
 	Notes        string                 `json:"notes"`         // 备注
 	Extra        map[string]interface{} `json:"extra"`         // 扩展字段
 }
 
+// ProductGrant 产品授权，一个许可证可以包含多个产品授权
+type ProductGrant struct {
+	Name       string    `json:"name"`                  // 产品名称
+	MinVersion string    `json:"min_version,omitempty"` // 最低版本（包含）
+	MaxVersion string    `json:"max_version,omitempty"` // 最高版本（包含）
+	Features   []string  `json:"features,omitempty"`    // 该产品允许的功能列表
+	ExpiresAt  time.Time `json:"expires_at"`            // 该产品的过期时间
+}
+
+// CheckInPolicy 签到策略
+// 许可证只在最近一次成功签到后的 LeaseDays 天内有效，取消订阅后签到服务器不再续期，许可证随之失效
+type CheckInPolicy struct {
+	URL       string `json:"url"`        // 签到地址
+	LeaseDays int    `json:"lease_days"` // 每次签到获得的租约有效期（天）
+}
+
+// MatchPolicy 机器匹配策略
+// 只有许可证中非空的组件参与匹配，MinMatches 和 MinScore 都为 0 时要求所有组件匹配
+type MatchPolicy struct {
+	MinMatches int            `json:"min_matches,omitempty"` // 至少匹配的组件数
+	MinScore   int            `json:"min_score,omitempty"`   // 匹配组件的最低权重总分
+	Weights    map[string]int `json:"weights,omitempty"`     // 组件权重，未设置的组件权重为 1
+}
+
+// ComponentMatch 机器指纹组件的匹配结果
+type ComponentMatch struct {
+	Component string `json:"component"` // 组件名称
+	Expected  string `json:"expected"`  // 许可证中的值
+	Actual    string `json:"actual"`    // 当前机器的值
+	Weight    int    `json:"weight"`    // 组件权重
+	Matched   bool   `json:"matched"`   // 是否匹配
+}
+
 // LicenseFile 许可证文件结构
 type LicenseFile struct {
 	Data      string `json:"data"`      // 加密的许可证数据
@@ -40,17 +92,24 @@ type LicenseFile struct {
 
 // VerificationResult 验证结果
 type VerificationResult struct {
-	Valid       bool      `json:"valid"`       // 是否有效
-	License     *License  `json:"license"`     // 许可证信息
-	Error       string    `json:"error"`       // 错误信息
-	VerifiedAt  time.Time `json:"verified_at"` // 验证时间
-	ExpiresIn   int64     `json:"expires_in"`  // 剩余有效期（秒）
+	Valid       bool          `json:"valid"`            // 是否有效
+	License     *License      `json:"license"`          // 许可证信息
+	Error       string        `json:"error"`            // 错误信息
+	Err         error         `json:"-"`                // 错误，可通过 errors.Is 与 ErrExpired 等比较
+	VerifiedAt  time.Time     `json:"verified_at"`      // 验证时间
+	ExpiresIn   int64         `json:"expires_in"`       // 剩余有效期（秒）
+	Cached      bool          `json:"cached,omitempty"` // 是否为 CachingVerifier 缓存的结果，VerifiedAt 和 ExpiresIn 为实际验证时的值
+	Grant       *ProductGrant `json:"grant,omitempty"`  // 当前产品的授权（设置了验证产品时）
 	MachineInfo struct {
 		MAC     string `json:"mac"`     // 当前机器MAC
 		UUID    string `json:"uuid"`    // 当前机器UUID
 		CPUID   string `json:"cpuid"`   // 当前机器CPU ID
 		Matched bool   `json:"matched"` // 机器信息是否匹配
+
+		Components []ComponentMatch `json:"components,omitempty"` // 各组件的匹配情况
+		Score      int              `json:"score"`                // 匹配组件的权重总分
 	} `json:"machine_info"`
+	Checks []CheckResult `json:"checks"` // 各检查项的结果
 }
 
 // GenerateOptions 生成许可证选项
@@ -66,6 +125,13 @@ type GenerateOptions struct {
 	UUID  string
 	CPUID string
 
+	// 多机器绑定，MaxMachines 为 0 时默认为机器列表的长度
+	Machines    []machine.MachineInfo
+	MaxMachines int
+
+	// 机器匹配策略
+	MatchPolicy *MatchPolicy
+
 	// 时间设置
 	Duration time.Duration // 有效期长度
 
@@ -73,6 +139,18 @@ type GenerateOptions struct {
 	Features []string
 	MaxUsers int
 
+	// 产品授权（多产品许可证），未设置过期时间的授权继承许可证的过期时间
+	Products []ProductGrant
+
+	// 签到策略，设置后许可证需要有效的签到租约
+	CheckIn *CheckInPolicy
+
+	// 要求验证器配置完整性清单，缺少清单时验证失败
+	RequireIntegrity bool
+
+	// 浮动许可证服务器签名席位租约的公钥（PEM），与签发私钥无关
+	LeaseKey string
+
 	// 扩展字段
 	Extra map[string]interface{}
 }
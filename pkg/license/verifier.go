@@ -1,10 +1,14 @@
 package license
 
 import (
+	"context"
 	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/cuilan/license-key-verify/pkg/crypto"
@@ -15,23 +19,99 @@ import (
 type Verifier struct {
 	publicKey *rsa.PublicKey
 	aesKey    []byte
+	keyID     string
+	logger    *slog.Logger
+
+	// 验证范围：设置后只接受包含该产品授权的许可证
+	productName    string
+	productVersion string
+
+	clock       Clock
+	fixedTime   bool // 时间由 WithTime 指定
+	machineInfo MachineInfoProvider
+
+	// 时钟回拨检测
+	timeStore      TimeStore
+	clockTolerance time.Duration
+
+	// 可信时间令牌，trustedTime 是所有令牌中最新的时间
+	timeTokens  [][]byte
+	trustedTime time.Time
+
+	// 吊销列表，可在运行期间更新
+	mu               sync.RWMutex
+	revocationLists  [][]byte
+	revocations      *RevocationList
+	revocationDigest string
+
+	// 签到租约，每次验证时重新读取
+	leaseLoader func() ([]byte, error)
+
+	// 可执行文件完整性清单
+	integrityManifest []byte
+	integrity         *IntegrityManifest
+	executable        executableHash
+
+	// 每次验证后调用，用于指标统计等
+	observers []func(*VerificationResult)
 }
 
 // NewVerifier 创建新的验证器
-func NewVerifier(publicKeyPEM []byte, aesKey []byte) (*Verifier, error) {
+func NewVerifier(publicKeyPEM []byte, aesKey []byte, opts ...Option) (*Verifier, error) {
 	publicKey, err := crypto.LoadPublicKeyFromPEM(publicKeyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load public key: %v", err)
 	}
 
-	return &Verifier{
-		publicKey: publicKey,
-		aesKey:    aesKey,
-	}, nil
+	keyID, err := crypto.KeyID(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Verifier{
+		publicKey:   publicKey,
+		keyID:       keyID,
+		aesKey:      aesKey,
+		clock:       systemClock{},
+		machineInfo: systemMachineInfo{},
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if store, ok := v.timeStore.(interface{ bindKey([]byte) }); ok {
+		store.bindKey(aesKey)
+	}
+
+	for _, data := range v.timeTokens {
+		token, err := v.ParseTimeToken(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time token: %v", err)
+		}
+		if token.Time.After(v.trustedTime) {
+			v.trustedTime = token.Time
+		}
+	}
+
+	for _, data := range v.revocationLists {
+		if err := v.LoadRevocationList(data); err != nil {
+			return nil, fmt.Errorf("invalid revocation list: %v", err)
+		}
+	}
+
+	if v.integrityManifest != nil {
+		v.integrity, err = v.ParseIntegrityManifest(v.integrityManifest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integrity manifest: %v", err)
+		}
+	}
+
+	return v, nil
 }
 
 // NewVerifierFromFiles 从文件创建验证器
-func NewVerifierFromFiles(publicKeyPath, aesKeyPath string) (*Verifier, error) {
+func NewVerifierFromFiles(publicKeyPath, aesKeyPath string, opts ...Option) (*Verifier, error) {
 	// 读取公钥
 	publicKeyPEM, err := os.ReadFile(publicKeyPath)
 	if err != nil {
@@ -49,132 +129,362 @@ func NewVerifierFromFiles(publicKeyPath, aesKeyPath string) (*Verifier, error) {
 		return nil, fmt.Errorf("failed to decode AES key: %v", err)
 	}
 
-	return NewVerifier(publicKeyPEM, aesKey)
+	return NewVerifier(publicKeyPEM, aesKey, opts...)
+}
+
+// SetProduct 设置验证的产品范围
+// 设置后，Verify 只在许可证包含该产品（及版本）的有效授权时通过，version 为空时不检查版本
+func (v *Verifier) SetProduct(name, version string) {
+	v.productName = name
+	v.productVersion = version
 }
 
 // VerifyFile 验证许可证文件
 func (v *Verifier) VerifyFile(filePath string) (*VerificationResult, error) {
+	return v.VerifyFileContext(context.Background(), filePath)
+}
+
+// VerifyFileContext 在 ctx 的限制内验证许可证文件
+func (v *Verifier) VerifyFileContext(ctx context.Context, filePath string) (*VerificationResult, error) {
 	// 读取许可证文件
 	fileData, err := os.ReadFile(filePath)
 	if err != nil {
-		return &VerificationResult{
-			Valid:      false,
-			Error:      fmt.Sprintf("failed to read license file: %v", err),
-			VerifiedAt: time.Now(),
-		}, nil
+		result := &VerificationResult{
+			VerifiedAt: v.clock.Now(),
+		}
+		result.fail(CheckFormat, "", "", ErrReadLicense, fmt.Sprintf("failed to read license file: %v", err))
+		v.report(ctx, result)
+		return result, nil
 	}
 
-	return v.Verify(fileData)
+	return v.VerifyContext(ctx, fileData)
 }
 
 // Verify 验证许可证数据
+// 格式、签名或解密失败时立即返回，其余检查项（时间、产品、机器）全部执行，
+// 每个检查项的结果记录在 VerificationResult.Checks 中
 func (v *Verifier) Verify(fileData []byte) (*VerificationResult, error) {
+	return v.VerifyContext(context.Background(), fileData)
+}
+
+// VerifyContext 在 ctx 的限制内验证许可证数据
+// 获取机器信息（可能启动外部命令）时 ctx 超时，机器检查项以 ErrTimeout 失败；被取消时以 context.Canceled 失败
+func (v *Verifier) VerifyContext(ctx context.Context, fileData []byte) (*VerificationResult, error) {
+	result, err := v.verify(ctx, fileData)
+	if err != nil {
+		return nil, err
+	}
+	v.report(ctx, result)
+	return result, nil
+}
+
+// report 记录日志并通知观察者
+func (v *Verifier) report(ctx context.Context, result *VerificationResult) {
+	v.logResult(ctx, result)
+	for _, observe := range v.observers {
+		observe(result)
+	}
+}
+
+// verify 执行各检查项
+func (v *Verifier) verify(ctx context.Context, fileData []byte) (*VerificationResult, error) {
 	result := &VerificationResult{
-		VerifiedAt: time.Now(),
+		VerifiedAt: v.clock.Now(),
+	}
+
+	license := v.decode(result, fileData)
+	if license == nil {
+		return result, nil
+	}
+	result.License = license
+
+	// 检查时间有效性
+	now := v.checkClock(result)
+	nowText := now.Format(time.RFC3339)
+	if now.Before(license.IssuedAt) {
+		result.fail(CheckNotBefore, license.IssuedAt.Format(time.RFC3339), nowText, ErrNotYetValid, "license is not yet valid")
+	} else {
+		result.pass(CheckNotBefore, license.IssuedAt.Format(time.RFC3339), nowText)
+	}
+
+	if now.After(license.ExpiresAt) {
+		result.fail(CheckExpiry, license.ExpiresAt.Format(time.RFC3339), nowText, ErrExpired, "license has expired")
+	} else {
+		result.pass(CheckExpiry, license.ExpiresAt.Format(time.RFC3339), nowText)
+		result.ExpiresIn = int64(license.ExpiresAt.Sub(now).Seconds())
+	}
+
+	// 检查吊销列表
+	v.checkRevocation(result, license)
+
+	// 检查签到租约
+	v.checkLease(result, license, now)
+
+	// 检查产品授权
+	v.checkProduct(result, license, now)
+
+	// 检查可执行文件完整性
+	v.checkIntegrity(result, license)
+
+	// 检查机器信息
+	v.checkMachine(ctx, result, license)
+
+	result.Valid = result.Error == ""
+
+	// 记录本次验证时间，保存失败不影响验证结果；WithTime 指定的时间不记录，避免影响之后的回拨检测
+	if result.Valid && v.timeStore != nil && !v.fixedTime {
+		_ = v.timeStore.Save(now)
+	}
+
+	return result, nil
+}
+
+// Decode 校验签名并解密许可证，不执行其余检查项，也不记录日志、通知观察者
+// 用于只需要读取许可证内容的场景（例如签到客户端在租约过期后读取签到地址）
+func (v *Verifier) Decode(fileData []byte) (*License, error) {
+	result := &VerificationResult{}
+	license := v.decode(result, fileData)
+	if license == nil {
+		return nil, result.Err
 	}
+	return license, nil
+}
 
+// decode 执行格式、签名和解密检查，失败时返回 nil
+func (v *Verifier) decode(result *VerificationResult, fileData []byte) *License {
 	// 解析许可证文件
 	var licenseFile LicenseFile
 	err := json.Unmarshal(fileData, &licenseFile)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to parse license file: %v", err)
-		return result, nil
+		result.fail(CheckFormat, "", "", ErrInvalidFormat, fmt.Sprintf("failed to parse license file: %v", err))
+		return nil
 	}
 
 	// 检查文件格式版本
 	if licenseFile.Version != FileFormatVersion {
-		result.Error = fmt.Sprintf("unsupported file format version: %s", licenseFile.Version)
-		return result, nil
+		result.fail(CheckFormat, FileFormatVersion, licenseFile.Version, ErrUnsupportedVersion,
+			fmt.Sprintf("unsupported file format version: %s", licenseFile.Version))
+		return nil
 	}
+	result.pass(CheckFormat, FileFormatVersion, licenseFile.Version)
 
 	// 解码数据和签名
 	encryptedData, err := crypto.DecodeBase64(licenseFile.Data)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to decode license data: %v", err)
-		return result, nil
+		result.fail(CheckSignature, "", "", ErrSignature, fmt.Sprintf("failed to decode license data: %v", err))
+		return nil
 	}
 
 	signature, err := crypto.DecodeBase64(licenseFile.Signature)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to decode signature: %v", err)
-		return result, nil
+		result.fail(CheckSignature, "", "", ErrSignature, fmt.Sprintf("failed to decode signature: %v", err))
+		return nil
 	}
 
 	// 验证签名
 	err = crypto.VerifySignature(encryptedData, signature, v.publicKey)
 	if err != nil {
-		result.Error = fmt.Sprintf("signature verification failed: %v", err)
-		return result, nil
+		result.fail(CheckSignature, "", "", ErrSignature, fmt.Sprintf("signature verification failed: %v", err))
+		return nil
 	}
+	result.pass(CheckSignature, "", "")
 
 	// 解密许可证数据
 	licenseData, err := crypto.DecryptAES(encryptedData, v.aesKey)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to decrypt license data: %v", err)
-		return result, nil
+		result.fail(CheckDecrypt, "", "", ErrDecrypt, fmt.Sprintf("failed to decrypt license data: %v", err))
+		return nil
 	}
 
 	// 解析许可证
 	var license License
 	err = json.Unmarshal(licenseData, &license)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to parse license: %v", err)
-		return result, nil
+		result.fail(CheckDecrypt, "", "", ErrInvalidFormat, fmt.Sprintf("failed to parse license: %v", err))
+		return nil
 	}
+	result.pass(CheckDecrypt, "", "")
 
-	result.License = &license
+	return &license
+}
 
-	// 检查时间有效性
-	now := time.Now()
-	if now.Before(license.IssuedAt) {
-		result.Error = "license is not yet valid"
-		return result, nil
+// checkClock 检测系统时钟回拨，返回用于有效期检查的时间
+// 时钟在容差范围内回拨时使用记录的时间，早于可信时间令牌时使用令牌的时间，避免通过回拨时钟延长有效期
+func (v *Verifier) checkClock(result *VerificationResult) time.Time {
+	now := v.checkTimeStore(result)
+
+	if v.trustedTime.IsZero() {
+		result.skip(CheckTimeToken, "no time token configured")
+		return now
 	}
 
-	if now.After(license.ExpiresAt) {
-		result.Error = "license has expired"
+	expected := "after " + v.trustedTime.Format(time.RFC3339)
+	if now.Before(v.trustedTime) {
+		result.Checks = append(result.Checks, CheckResult{
+			Name:     CheckTimeToken,
+			Status:   CheckPassed,
+			Expected: expected,
+			Actual:   now.Format(time.RFC3339),
+			Message:  "local clock is behind the time token, using the token time",
+		})
+		return v.trustedTime
+	}
+	result.pass(CheckTimeToken, expected, now.Format(time.RFC3339))
+
+	return now
+}
+
+// checkTimeStore 根据记录的最后验证时间检测系统时钟回拨
+// WithTime 指定的时间不是系统时钟，早于记录的时间不代表回拨，跳过检测
+func (v *Verifier) checkTimeStore(result *VerificationResult) time.Time {
+	now := result.VerifiedAt
+	if v.timeStore == nil {
+		result.skip(CheckClock, "no time store configured")
+		return now
+	}
+	if v.fixedTime {
+		result.skip(CheckClock, "verification time is set explicitly")
+		return now
+	}
+
+	lastSeen, err := v.timeStore.Load()
+	if err != nil {
+		result.fail(CheckClock, "", "", ErrTimeStore, fmt.Sprintf("failed to load time store: %v", err))
+		return now
+	}
+
+	expected := "after " + lastSeen.Add(-v.clockTolerance).Format(time.RFC3339)
+	if now.Before(lastSeen.Add(-v.clockTolerance)) {
+		result.fail(CheckClock, expected, now.Format(time.RFC3339), ErrClockRollback,
+			fmt.Sprintf("system clock has been rolled back (last verified at %s)", lastSeen.Format(time.RFC3339)))
+		return lastSeen
+	}
+	result.pass(CheckClock, expected, now.Format(time.RFC3339))
+
+	if now.Before(lastSeen) {
+		return lastSeen
+	}
+	return now
+}
+
+// checkProduct 检查许可证是否包含当前产品的有效授权
+func (v *Verifier) checkProduct(result *VerificationResult, license *License, now time.Time) {
+	if v.productName == "" {
+		result.skip(CheckProduct, "no product scope configured")
+		return
+	}
+
+	expected := v.productName
+	if v.productVersion != "" {
+		expected += " " + v.productVersion
+	}
+
+	grant, err := license.GrantFor(v.productName, v.productVersion)
+	if err != nil {
+		result.fail(CheckProduct, expected, license.ProductName, ErrProductMismatch, err.Error())
+		return
+	}
+	result.Grant = grant
+
+	if now.After(grant.ExpiresAt) {
+		result.fail(CheckProduct, grant.ExpiresAt.Format(time.RFC3339), now.Format(time.RFC3339), ErrExpired,
+			fmt.Sprintf("license for product %q has expired", grant.Name))
 		result.ExpiresIn = 0
-		return result, nil
+		return
 	}
+	result.pass(CheckProduct, expected, grant.Name)
 
-	result.ExpiresIn = int64(license.ExpiresAt.Sub(now).Seconds())
+	if expiresIn := int64(grant.ExpiresAt.Sub(now).Seconds()); expiresIn < result.ExpiresIn {
+		result.ExpiresIn = expiresIn
+	}
+}
 
-	// 获取当前机器信息
-	machineInfo, err := machine.GetAllInfo()
+// checkMachine 检查当前机器是否与许可证允许的任意一台机器匹配
+func (v *Verifier) checkMachine(ctx context.Context, result *VerificationResult, license *License) {
+	machineInfo, err := getMachineInfo(ctx, v.machineInfo)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to get machine info: %v", err)
-		return result, nil
+		errType := ErrMachineInfo
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			errType = ErrTimeout
+		case errors.Is(err, context.Canceled):
+			errType = context.Canceled
+		}
+		result.fail(CheckMachine, "", "", errType, fmt.Sprintf("failed to get machine info: %v", err))
+		return
 	}
 
 	result.MachineInfo.MAC = machineInfo.MAC
 	result.MachineInfo.UUID = machineInfo.UUID
 	result.MachineInfo.CPUID = machineInfo.CPUID
 
-	// 验证机器信息
-	machineMatched := true
-
-	if license.MAC != "" && license.MAC != machineInfo.MAC {
-		machineMatched = false
+	if license.MaxMachines > 0 && len(license.Machines) > license.MaxMachines {
+		result.fail(CheckMachine, fmt.Sprintf("<= %d machines", license.MaxMachines),
+			fmt.Sprintf("%d machines", len(license.Machines)), ErrMachineMismatch, "license lists more machines than allowed")
+		return
 	}
 
-	if license.UUID != "" && license.UUID != machineInfo.UUID {
-		machineMatched = false
+	allowed := license.AllowedMachines()
+	if len(allowed) == 0 {
+		result.MachineInfo.Matched = true
+		for _, name := range machine.ComponentNames {
+			result.skip(name, "license is not bound to a machine")
+		}
+		result.skip(CheckMachine, "license is not bound to a machine")
+		return
 	}
 
-	if license.CPUID != "" && license.CPUID != machineInfo.CPUID {
-		machineMatched = false
+	// 与允许的任意一台机器匹配即可，未匹配时报告得分最高的机器，便于排查
+	bestScore := -1
+	for _, expected := range allowed {
+		components, score, matched := matchMachine(expected, machineInfo, license.MatchPolicy)
+		if matched || score > bestScore {
+			result.MachineInfo.Components = components
+			result.MachineInfo.Score = score
+			bestScore = score
+		}
+		if matched {
+			result.MachineInfo.Matched = true
+			break
+		}
 	}
 
-	result.MachineInfo.Matched = machineMatched
+	// 各组件的检查结果
+	for _, name := range machine.ComponentNames {
+		var cm *ComponentMatch
+		for i := range result.MachineInfo.Components {
+			if result.MachineInfo.Components[i].Component == name {
+				cm = &result.MachineInfo.Components[i]
+			}
+		}
+
+		switch {
+		case cm == nil:
+			result.skip(name, "component is not bound")
+		case cm.Matched:
+			result.pass(name, cm.Expected, cm.Actual)
+		default:
+			// 组件不匹配本身不导致验证失败，是否通过由整体匹配策略决定
+			result.Checks = append(result.Checks, CheckResult{
+				Name:     name,
+				Status:   CheckFailed,
+				Expected: cm.Expected,
+				Actual:   cm.Actual,
+				Message:  fmt.Sprintf("%s does not match", name),
+			})
+		}
+	}
 
-	if !machineMatched {
-		result.Error = "machine information does not match"
-		return result, nil
+	summary := fmt.Sprintf("all bound components of one of %d machines", len(allowed))
+	if license.MatchPolicy != nil {
+		summary = fmt.Sprintf("min matches %d, min score %d", license.MatchPolicy.MinMatches, license.MatchPolicy.MinScore)
 	}
 
-	// 验证通过
-	result.Valid = true
-	return result, nil
+	if !result.MachineInfo.Matched {
+		result.fail(CheckMachine, summary, fmt.Sprintf("score %d", result.MachineInfo.Score), ErrMachineMismatch, "machine information does not match")
+		return
+	}
+	result.pass(CheckMachine, summary, fmt.Sprintf("score %d", result.MachineInfo.Score))
 }
 
 // GetLicenseInfo 获取许可证信息（不验证机器信息）
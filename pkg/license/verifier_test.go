@@ -0,0 +1,350 @@
+package license_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+	"github.com/cuilan/license-key-verify/pkg/machine"
+)
+
+const (
+	testMAC   = "00:11:22:33:44:55"
+	testUUID  = "4c4c4544-0000-1000-8000-000000000000"
+	testCPUID = "0123456789abcdef0123456789abcdef"
+)
+
+// newTestSetup 创建生成器以及使用假时钟和假机器信息的验证器
+func newTestSetup(t *testing.T, opts ...license.Option) (*license.Generator, *license.Verifier, *licensetest.Clock, *licensetest.MachineInfo) {
+	t.Helper()
+
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+
+	return newTestSetupWithKeys(t, generator, opts...)
+}
+
+// newTestSetupWithKeys 使用已有生成器的密钥创建验证器
+func newTestSetupWithKeys(t *testing.T, generator *license.Generator, opts ...license.Option) (*license.Generator, *license.Verifier, *licensetest.Clock, *licensetest.MachineInfo) {
+	t.Helper()
+
+	publicKeyPEM, err := generator.GetPublicKeyPEM()
+	if err != nil {
+		t.Fatalf("GetPublicKeyPEM() error = %v", err)
+	}
+
+	// 时钟比许可证签发时间稍晚，避免与生成器的系统时间竞争
+	clock := licensetest.NewClock(time.Now().Add(time.Minute))
+	info := licensetest.NewMachineInfo(testMAC, testUUID, testCPUID)
+
+	opts = append([]license.Option{
+		license.WithClock(clock),
+		license.WithMachineInfoProvider(info),
+	}, opts...)
+
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(), opts...)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	return generator, verifier, clock, info
+}
+
+// issue 生成并编码许可证
+func issue(t *testing.T, generator *license.Generator, options *license.GenerateOptions) []byte {
+	t.Helper()
+
+	lic, err := generator.Generate(options)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	data, err := generator.Encode(lic)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	return data
+}
+
+func TestVerifyValid(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{
+		MAC:      testMAC,
+		UUID:     testUUID,
+		CPUID:    testCPUID,
+		Duration: 24 * time.Hour,
+	})
+
+	result, err := verifier.Verify(data)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+	if result.Err != nil {
+		t.Errorf("Verify() Err = %v, want nil", result.Err)
+	}
+	if !result.MachineInfo.Matched {
+		t.Error("Verify() machine should match")
+	}
+	if len(result.FailedChecks()) != 0 {
+		t.Errorf("Verify() failed checks = %v", result.FailedChecks())
+	}
+}
+
+func TestVerifyChecks(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{Duration: -time.Hour})
+
+	result, _ := verifier.Verify(data)
+	if result.Valid {
+		t.Fatal("Verify() should fail for an expired license")
+	}
+
+	want := map[string]license.CheckStatus{
+		license.CheckFormat:    license.CheckPassed,
+		license.CheckSignature: license.CheckPassed,
+		license.CheckDecrypt:   license.CheckPassed,
+		license.CheckExpiry:    license.CheckFailed,
+		license.CheckProduct:   license.CheckSkipped,
+		license.CheckMachine:   license.CheckSkipped,
+	}
+	for name, status := range want {
+		if c := result.Check(name); c == nil || c.Status != status {
+			t.Errorf("Verify() %s check = %+v, want %s", name, c, status)
+		}
+	}
+	if failed := result.FailedChecks(); len(failed) != 1 || failed[0].Name != license.CheckExpiry {
+		t.Errorf("Verify() failed checks = %+v, want only expiry", failed)
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	_, verifier, _, _ := newTestSetup(t)
+
+	result, _ := verifier.VerifyFile(filepath.Join(t.TempDir(), "missing.lic"))
+	if !errors.Is(result.Err, license.ErrReadLicense) {
+		t.Errorf("VerifyFile() Err = %v, want %v", result.Err, license.ErrReadLicense)
+	}
+
+	result, _ = verifier.Verify([]byte("not a license"))
+	if !errors.Is(result.Err, license.ErrInvalidFormat) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrInvalidFormat)
+	}
+}
+
+func TestVerifyTimeBoundaries(t *testing.T) {
+	generator, verifier, clock, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{Duration: 24 * time.Hour})
+
+	tests := []struct {
+		name    string
+		offset  time.Duration
+		wantErr error
+	}{
+		{"before issue", -time.Hour, license.ErrNotYetValid},
+		{"just before expiry", 24*time.Hour - 2*time.Minute, nil},
+		{"after expiry", 24 * time.Hour, license.ErrExpired},
+	}
+
+	start := clock.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Set(start.Add(tt.offset))
+
+			result, err := verifier.Verify(data)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if result.Valid != (tt.wantErr == nil) {
+				t.Fatalf("Verify() valid = %v, error = %s", result.Valid, result.Error)
+			}
+			if tt.wantErr != nil && !errors.Is(result.Err, tt.wantErr) {
+				t.Errorf("Verify() Err = %v, want %v", result.Err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyMachineMismatch(t *testing.T) {
+	generator, verifier, _, info := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{
+		MAC:   testMAC,
+		UUID:  testUUID,
+		CPUID: testCPUID,
+	})
+
+	info.Set(machine.MachineInfo{MAC: "66:77:88:99:aa:bb", UUID: testUUID, CPUID: testCPUID})
+
+	result, err := verifier.Verify(data)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Valid {
+		t.Fatal("Verify() should fail on machine mismatch")
+	}
+
+	var verr *license.VerificationError
+	if !errors.As(result.Err, &verr) || verr.Check != license.CheckMachine {
+		t.Errorf("Verify() Err = %v, want machine check failure", result.Err)
+	}
+	if !errors.Is(result.Err, license.ErrMachineMismatch) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrMachineMismatch)
+	}
+	if c := result.Check(machine.ComponentMAC); c == nil || c.Status != license.CheckFailed {
+		t.Errorf("Verify() mac check = %+v, want failed", c)
+	}
+	if c := result.Check(machine.ComponentUUID); c == nil || c.Status != license.CheckPassed {
+		t.Errorf("Verify() uuid check = %+v, want passed", c)
+	}
+}
+
+func TestVerifyTolerantMatch(t *testing.T) {
+	generator, verifier, _, info := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{
+		MAC:         testMAC,
+		UUID:        testUUID,
+		CPUID:       testCPUID,
+		MatchPolicy: &license.MatchPolicy{MinMatches: 2},
+	})
+
+	// 更换网卡后仍然有效
+	info.Set(machine.MachineInfo{MAC: "66:77:88:99:aa:bb", UUID: testUUID, CPUID: testCPUID})
+	result, _ := verifier.Verify(data)
+	if !result.Valid {
+		t.Errorf("Verify() with 2 of 3 components invalid: %s", result.Error)
+	}
+
+	// 同时更换网卡和主板后失效
+	info.Set(machine.MachineInfo{MAC: "66:77:88:99:aa:bb", UUID: "other", CPUID: testCPUID})
+	result, _ = verifier.Verify(data)
+	if result.Valid {
+		t.Error("Verify() with 1 of 3 components should fail")
+	}
+	if result.MachineInfo.Score != 1 {
+		t.Errorf("Verify() score = %d, want 1", result.MachineInfo.Score)
+	}
+}
+
+func TestVerifyMachineList(t *testing.T) {
+	generator, verifier, _, info := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{
+		Machines: []machine.MachineInfo{
+			{MAC: "aa:aa:aa:aa:aa:aa"},
+			{MAC: testMAC, CPUID: testCPUID},
+		},
+	})
+
+	result, _ := verifier.Verify(data)
+	if !result.Valid {
+		t.Errorf("Verify() on second listed machine invalid: %s", result.Error)
+	}
+
+	info.Set(machine.MachineInfo{MAC: "bb:bb:bb:bb:bb:bb"})
+	result, _ = verifier.Verify(data)
+	if result.Valid {
+		t.Error("Verify() on unlisted machine should fail")
+	}
+}
+
+func TestVerifyProductGrant(t *testing.T) {
+	generator, verifier, clock, _ := newTestSetup(t, license.WithProduct("Editor", "1.5.0"))
+	data := issue(t, generator, &license.GenerateOptions{
+		Duration: 30 * 24 * time.Hour,
+		Products: []license.ProductGrant{
+			{Name: "Editor", MinVersion: "1.0", MaxVersion: "1.9.9", ExpiresAt: clock.Now().Add(7 * 24 * time.Hour)},
+			{Name: "Viewer"},
+		},
+	})
+
+	result, _ := verifier.Verify(data)
+	if !result.Valid {
+		t.Fatalf("Verify() invalid: %s", result.Error)
+	}
+	if result.Grant == nil || result.Grant.Name != "Editor" {
+		t.Errorf("Verify() grant = %+v, want Editor", result.Grant)
+	}
+
+	verifier.SetProduct("Editor", "2.0.0")
+	result, _ = verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrProductMismatch) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrProductMismatch)
+	}
+
+	verifier.SetProduct("Editor", "")
+	clock.Advance(8 * 24 * time.Hour)
+	result, _ = verifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrExpired) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrExpired)
+	}
+}
+
+func TestVerifyWrongKeys(t *testing.T) {
+	generator, _, _, _ := newTestSetup(t)
+	_, otherVerifier, _, _ := newTestSetup(t)
+	data := issue(t, generator, &license.GenerateOptions{})
+
+	result, _ := otherVerifier.Verify(data)
+	if !errors.Is(result.Err, license.ErrSignature) {
+		t.Errorf("Verify() Err = %v, want %v", result.Err, license.ErrSignature)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	observed := 0
+	generator, verifier, clock, _ := newTestSetup(t, license.WithObserver(func(*license.VerificationResult) {
+		observed++
+	}))
+	data := issue(t, generator, &license.GenerateOptions{ProductName: "Editor", Duration: 24 * time.Hour})
+
+	// 过期的许可证仍然可以解出
+	clock.Advance(48 * time.Hour)
+	lic, err := verifier.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if lic.ProductName != "Editor" {
+		t.Errorf("Decode() ProductName = %q, want %q", lic.ProductName, "Editor")
+	}
+
+	_, otherVerifier, _, _ := newTestSetup(t)
+	if _, err := otherVerifier.Decode(data); !errors.Is(err, license.ErrSignature) {
+		t.Errorf("Decode() with other keys error = %v, want %v", err, license.ErrSignature)
+	}
+
+	if observed != 0 {
+		t.Errorf("Decode() notified observers %d times, want 0", observed)
+	}
+}
+
+func TestVerifyContextTimeout(t *testing.T) {
+	// 模拟挂起的外部命令
+	hung := license.MachineInfoFunc(func() (*machine.MachineInfo, error) {
+		time.Sleep(time.Second)
+		return &machine.MachineInfo{}, nil
+	})
+	generator, verifier, _, _ := newTestSetup(t, license.WithMachineInfoProvider(hung))
+	data := issue(t, generator, &license.GenerateOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := verifier.VerifyContext(ctx, data)
+	if err != nil {
+		t.Fatalf("VerifyContext() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("VerifyContext() took %s, want it to return at the deadline", elapsed)
+	}
+	if !errors.Is(result.Err, license.ErrTimeout) {
+		t.Errorf("VerifyContext() Err = %v, want %v", result.Err, license.ErrTimeout)
+	}
+}
@@ -0,0 +1,268 @@
+package license
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 监视器默认参数
+const (
+	DefaultWatchInterval = time.Minute      // 默认的重新验证间隔
+	DefaultPollInterval  = 10 * time.Second // 默认的文件变化检查间隔
+)
+
+// DefaultExpiryThresholds 默认的即将过期提醒阈值：30天、7天、1天
+var DefaultExpiryThresholds = []time.Duration{30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour}
+
+// Watcher 许可证监视器
+// 定期重新验证许可证文件，并在文件内容变化时立即验证，用于长期运行的服务：
+// 许可证在运行期间过期、被替换或即将过期时通过回调通知调用方
+type Watcher struct {
+	verifier     *Verifier
+	path         string
+	reloadable   *ReloadableVerifier // 不为 nil 时验证其当前的许可证，忽略 verifier 和 path
+	interval     time.Duration
+	pollInterval time.Duration
+	thresholds   []time.Duration
+
+	onInvalid  func(result *VerificationResult)
+	onValid    func(result *VerificationResult)
+	onExpiring func(result *VerificationResult, threshold time.Duration)
+	onReplaced func(old, new *VerificationResult)
+
+	mu       sync.Mutex
+	result   *VerificationResult
+	hash     [sha256.Size]byte
+	modTime  time.Time
+	size     int64
+	loaded   *reloadState
+	notified map[time.Duration]bool
+}
+
+// WatcherOption 监视器选项
+type WatcherOption func(*Watcher)
+
+// WithWatchInterval 设置重新验证间隔和文件变化检查间隔
+func WithWatchInterval(interval, pollInterval time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.interval = interval
+		w.pollInterval = pollInterval
+	}
+}
+
+// WithExpiryThresholds 设置即将过期提醒阈值，每个阈值对同一份许可证只提醒一次
+func WithExpiryThresholds(thresholds ...time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.thresholds = thresholds
+	}
+}
+
+// OnInvalid 许可证从有效变为无效时调用（首次验证即无效时也会调用）
+func OnInvalid(fn func(result *VerificationResult)) WatcherOption {
+	return func(w *Watcher) {
+		w.onInvalid = fn
+	}
+}
+
+// OnValid 许可证从无效恢复为有效时调用，例如替换了续期后的许可证文件
+func OnValid(fn func(result *VerificationResult)) WatcherOption {
+	return func(w *Watcher) {
+		w.onValid = fn
+	}
+}
+
+// OnExpiring 有效许可证的剩余时间首次低于某个提醒阈值时调用
+func OnExpiring(fn func(result *VerificationResult, threshold time.Duration)) WatcherOption {
+	return func(w *Watcher) {
+		w.onExpiring = fn
+	}
+}
+
+// OnReplaced 许可证文件内容发生变化时调用，old 为变化前最后一次的验证结果
+// 文件被删除或无法读取时不调用（通过 OnInvalid 报告），之后出现的文件与删除前的内容比较
+func OnReplaced(fn func(old, new *VerificationResult)) WatcherOption {
+	return func(w *Watcher) {
+		w.onReplaced = fn
+	}
+}
+
+// NewWatcher 创建许可证监视器，调用 Run 开始监视
+func NewWatcher(verifier *Verifier, path string, opts ...WatcherOption) *Watcher {
+	return newWatcher(&Watcher{verifier: verifier, path: path}, opts...)
+}
+
+// NewWatcher 创建监视当前许可证的监视器，每次验证都使用最近一次重新加载的验证器和许可证，
+// 重新加载后立即验证；文件变化由 Watch 检测，监视器只负责定期验证（例如检查是否已过期）
+func (r *ReloadableVerifier) NewWatcher(opts ...WatcherOption) *Watcher {
+	return newWatcher(&Watcher{reloadable: r}, opts...)
+}
+
+// newWatcher 设置监视器的默认参数并应用选项
+func newWatcher(w *Watcher, opts ...WatcherOption) *Watcher {
+	*w = Watcher{
+		verifier:     w.verifier,
+		path:         w.path,
+		reloadable:   w.reloadable,
+		interval:     DefaultWatchInterval,
+		pollInterval: DefaultPollInterval,
+		thresholds:   DefaultExpiryThresholds,
+		notified:     make(map[time.Duration]bool),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	// 从大到小排列，剩余时间同时低于多个阈值时只提醒最小的一个
+	w.thresholds = append([]time.Duration(nil), w.thresholds...)
+	sort.Slice(w.thresholds, func(i, j int) bool { return w.thresholds[i] > w.thresholds[j] })
+
+	return w
+}
+
+// Result 返回最近一次的验证结果，尚未验证时返回 nil
+func (w *Watcher) Result() *VerificationResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.result
+}
+
+// Run 立即验证一次，之后按间隔重新验证并检查文件变化，直到 ctx 取消
+// 许可证在下次定期验证之前过期时，会在过期时刻额外验证一次
+func (w *Watcher) Run(ctx context.Context) error {
+	w.Check()
+	lastVerify := time.Now()
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if w.changed() || time.Since(lastVerify) >= w.nextVerify() {
+			w.Check()
+			lastVerify = time.Now()
+		}
+	}
+}
+
+// Check 立即读取并验证许可证文件，触发相应的回调，返回验证结果
+func (w *Watcher) Check() *VerificationResult {
+	var (
+		result  *VerificationResult
+		hash    [sha256.Size]byte
+		read    bool
+		modTime time.Time
+		size    int64
+	)
+
+	var loaded *reloadState
+	if w.reloadable != nil {
+		loaded = w.reloadable.state.Load()
+		hash, read = sha256.Sum256(loaded.licenseData), true
+		result, _ = loaded.verifier.Verify(loaded.licenseData)
+	} else if data, err := os.ReadFile(w.path); err != nil {
+		result, _ = w.verifier.VerifyFile(w.path)
+	} else {
+		hash, read = sha256.Sum256(data), true
+		if info, err := os.Stat(w.path); err == nil {
+			modTime, size = info.ModTime(), info.Size()
+		}
+		result, _ = w.verifier.Verify(data)
+	}
+
+	w.mu.Lock()
+	old := w.result
+	// 只有读取到内容且与上次读取到的内容不同时才算替换，w.hash 保留最后一次读取成功的内容
+	replaced := read && w.hash != [sha256.Size]byte{} && hash != w.hash
+	if replaced {
+		w.notified = make(map[time.Duration]bool)
+	}
+	if read {
+		w.hash = hash
+	}
+	w.result = result
+	w.modTime, w.size = modTime, size
+	w.loaded = loaded
+	expiring := w.expiringThreshold(result)
+	w.mu.Unlock()
+
+	if replaced && w.onReplaced != nil {
+		w.onReplaced(old, result)
+	}
+
+	switch {
+	case !result.Valid && (old == nil || old.Valid):
+		if w.onInvalid != nil {
+			w.onInvalid(result)
+		}
+	case result.Valid && old != nil && !old.Valid:
+		if w.onValid != nil {
+			w.onValid(result)
+		}
+	}
+
+	if expiring > 0 && w.onExpiring != nil {
+		w.onExpiring(result, expiring)
+	}
+
+	return result
+}
+
+// expiringThreshold 返回本次新越过的最小提醒阈值，没有时返回 0，调用方需持有锁
+func (w *Watcher) expiringThreshold(result *VerificationResult) time.Duration {
+	if !result.Valid {
+		return 0
+	}
+
+	remaining := time.Duration(result.ExpiresIn) * time.Second
+	var crossed time.Duration
+	for _, threshold := range w.thresholds {
+		if remaining <= threshold && !w.notified[threshold] {
+			w.notified[threshold] = true
+			crossed = threshold
+		}
+	}
+	return crossed
+}
+
+// changed 检查文件的修改时间和大小是否变化，监视 ReloadableVerifier 时检查是否重新加载过
+func (w *Watcher) changed() bool {
+	if w.reloadable != nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.reloadable.state.Load() != w.loaded
+	}
+
+	info, err := os.Stat(w.path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err != nil {
+		// 文件被删除：上次读取成功时需要重新验证
+		return w.size != 0 || !w.modTime.IsZero()
+	}
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+// nextVerify 返回距下次定期验证的间隔，许可证在此之前过期时提前到过期时刻
+func (w *Watcher) nextVerify() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.result != nil && w.result.Valid {
+		if untilExpiry := time.Duration(w.result.ExpiresIn)*time.Second + time.Second; untilExpiry < w.interval {
+			return untilExpiry
+		}
+	}
+	return w.interval
+}
@@ -0,0 +1,138 @@
+package license_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+func TestWatcherCallbacks(t *testing.T) {
+	generator, verifier, clock, _ := newTestSetup(t)
+	path := filepath.Join(t.TempDir(), "license.lic")
+	os.WriteFile(path, issue(t, generator, &license.GenerateOptions{Duration: 10 * 24 * time.Hour}), 0644)
+
+	var (
+		invalid, valid, replaced int
+		expiring                 []time.Duration
+	)
+	watcher := license.NewWatcher(verifier, path,
+		license.OnInvalid(func(*license.VerificationResult) { invalid++ }),
+		license.OnValid(func(*license.VerificationResult) { valid++ }),
+		license.OnReplaced(func(old, new *license.VerificationResult) { replaced++ }),
+		license.OnExpiring(func(_ *license.VerificationResult, threshold time.Duration) {
+			expiring = append(expiring, threshold)
+		}))
+
+	if result := watcher.Check(); !result.Valid {
+		t.Fatalf("Check() invalid: %s", result.Error)
+	}
+	watcher.Check()
+	if len(expiring) != 1 || expiring[0] != 30*24*time.Hour {
+		t.Errorf("expiring = %v, want a single 30 day reminder", expiring)
+	}
+
+	clock.Advance(4 * 24 * time.Hour)
+	watcher.Check()
+	if len(expiring) != 2 || expiring[1] != 7*24*time.Hour {
+		t.Errorf("expiring = %v, want a 7 day reminder", expiring)
+	}
+
+	// 许可证在运行期间过期
+	clock.Advance(7 * 24 * time.Hour)
+	if result := watcher.Check(); result.Valid {
+		t.Fatal("Check() should report the expired license")
+	}
+	watcher.Check()
+	if invalid != 1 {
+		t.Errorf("invalid callbacks = %d, want 1", invalid)
+	}
+
+	// 替换为续期后的许可证
+	os.WriteFile(path, issue(t, generator, &license.GenerateOptions{Duration: 365 * 24 * time.Hour}), 0644)
+	if result := watcher.Check(); !result.Valid {
+		t.Fatalf("Check() after replacement invalid: %s", result.Error)
+	}
+	if replaced != 1 || valid != 1 {
+		t.Errorf("replaced = %d, valid = %d, want 1 and 1", replaced, valid)
+	}
+	if len(expiring) != 2 {
+		t.Errorf("expiring = %v, want no reminder for the renewed license", expiring)
+	}
+}
+
+func TestWatcherRunDetectsChange(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+	path := filepath.Join(t.TempDir(), "license.lic")
+	os.WriteFile(path, issue(t, generator, &license.GenerateOptions{}), 0644)
+
+	replaced := make(chan *license.VerificationResult, 1)
+	watcher := license.NewWatcher(verifier, path,
+		license.WithWatchInterval(time.Hour, 10*time.Millisecond),
+		license.OnReplaced(func(old, new *license.VerificationResult) { replaced <- new }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Run(ctx)
+
+	for watcher.Result() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	os.WriteFile(path, issue(t, generator, &license.GenerateOptions{CustomerName: "Renewed"}), 0644)
+
+	select {
+	case result := <-replaced:
+		if result.License == nil || result.License.CustomerName != "Renewed" {
+			t.Errorf("OnReplaced() new license = %+v, want the renewed license", result.License)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not detect the replaced license file")
+	}
+}
+
+func TestWatcherFileDeleted(t *testing.T) {
+	generator, verifier, _, _ := newTestSetup(t)
+	path := filepath.Join(t.TempDir(), "license.lic")
+	original := issue(t, generator, &license.GenerateOptions{})
+	os.WriteFile(path, original, 0644)
+
+	var invalid, valid, replaced int
+	watcher := license.NewWatcher(verifier, path,
+		license.OnInvalid(func(*license.VerificationResult) { invalid++ }),
+		license.OnValid(func(*license.VerificationResult) { valid++ }),
+		license.OnReplaced(func(old, new *license.VerificationResult) { replaced++ }))
+
+	if result := watcher.Check(); !result.Valid {
+		t.Fatalf("Check() invalid: %s", result.Error)
+	}
+
+	// 删除文件报告为无效，不是替换
+	os.Remove(path)
+	if result := watcher.Check(); result.Valid {
+		t.Fatal("Check() should report the deleted license file")
+	}
+	if invalid != 1 || replaced != 0 {
+		t.Errorf("invalid = %d, replaced = %d, want 1 and 0", invalid, replaced)
+	}
+
+	// 恢复同一份许可证不是替换
+	os.WriteFile(path, original, 0644)
+	if result := watcher.Check(); !result.Valid {
+		t.Fatalf("Check() after restore invalid: %s", result.Error)
+	}
+	if valid != 1 || replaced != 0 {
+		t.Errorf("valid = %d, replaced = %d, want 1 and 0", valid, replaced)
+	}
+
+	// 删除后放入新的许可证，与删除前的内容比较
+	os.Remove(path)
+	watcher.Check()
+	os.WriteFile(path, issue(t, generator, &license.GenerateOptions{CustomerName: "Renewed"}), 0644)
+	watcher.Check()
+	if replaced != 1 {
+		t.Errorf("replaced = %d, want 1", replaced)
+	}
+}
@@ -1,7 +1,9 @@
 package machine
 
 import (
+	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -10,6 +12,9 @@ import (
 	"strings"
 )
 
+// ErrTimeout 获取机器信息超时，外部命令（如 powershell、system_profiler）在截止时间前没有返回
+var ErrTimeout = errors.New("timed out getting machine info")
+
 // MachineInfo 机器信息结构体
 type MachineInfo struct {
 	MAC   string `json:"mac"`
@@ -17,6 +22,29 @@ type MachineInfo struct {
 	CPUID string `json:"cpuid"`
 }
 
+// 机器指纹组件名称
+const (
+	ComponentMAC   = "mac"
+	ComponentUUID  = "uuid"
+	ComponentCPUID = "cpuid"
+)
+
+// ComponentNames 机器指纹的所有组件名称
+var ComponentNames = []string{ComponentMAC, ComponentUUID, ComponentCPUID}
+
+// Component 返回指定名称的指纹组件值，未知组件返回空字符串
+func (m *MachineInfo) Component(name string) string {
+	switch name {
+	case ComponentMAC:
+		return m.MAC
+	case ComponentUUID:
+		return m.UUID
+	case ComponentCPUID:
+		return m.CPUID
+	}
+	return ""
+}
+
 // GetMACAddress 获取MAC地址
 func GetMACAddress() (string, error) {
 	interfaces, err := net.Interfaces()
@@ -39,30 +67,35 @@ func GetMACAddress() (string, error) {
 
 // GetSystemUUID 获取系统UUID
 func GetSystemUUID() (string, error) {
+	return GetSystemUUIDContext(context.Background())
+}
+
+// GetSystemUUIDContext 获取系统UUID，ctx 取消或超时时终止外部命令
+func GetSystemUUIDContext(ctx context.Context) (string, error) {
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
 	case "darwin":
-		cmd = exec.Command("system_profiler", "SPHardwareDataType")
+		cmd = exec.CommandContext(ctx, "system_profiler", "SPHardwareDataType")
 	case "linux":
 		// 尝试多种方式获取UUID
 		if _, err := os.Stat("/sys/class/dmi/id/product_uuid"); err == nil {
-			cmd = exec.Command("cat", "/sys/class/dmi/id/product_uuid")
+			cmd = exec.CommandContext(ctx, "cat", "/sys/class/dmi/id/product_uuid")
 		} else if _, err := os.Stat("/proc/sys/kernel/random/uuid"); err == nil {
-			cmd = exec.Command("cat", "/proc/sys/kernel/random/uuid")
+			cmd = exec.CommandContext(ctx, "cat", "/proc/sys/kernel/random/uuid")
 		} else {
 			return "", fmt.Errorf("failed to get system UUID")
 		}
 	case "windows":
 		// 使用PowerShell替代已弃用的wmic命令
-		cmd = exec.Command("powershell", "-Command", "Get-CimInstance -ClassName Win32_ComputerSystemProduct | Select-Object -ExpandProperty UUID")
+		cmd = exec.CommandContext(ctx, "powershell", "-Command", "Get-CimInstance -ClassName Win32_ComputerSystemProduct | Select-Object -ExpandProperty UUID")
 	default:
 		return "", fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
 
 	output, err := cmd.Output()
 	if err != nil {
-		return "", fmt.Errorf("failed to execute command: %v", err)
+		return "", commandError(ctx, err)
 	}
 
 	uuid := parseUUID(string(output), runtime.GOOS)
@@ -75,23 +108,28 @@ func GetSystemUUID() (string, error) {
 
 // GetCPUID 获取CPU ID
 func GetCPUID() (string, error) {
+	return GetCPUIDContext(context.Background())
+}
+
+// GetCPUIDContext 获取CPU ID，ctx 取消或超时时终止外部命令
+func GetCPUIDContext(ctx context.Context) (string, error) {
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
 	case "darwin":
-		cmd = exec.Command("sysctl", "-n", "machdep.cpu.brand_string")
+		cmd = exec.CommandContext(ctx, "sysctl", "-n", "machdep.cpu.brand_string")
 	case "linux":
-		cmd = exec.Command("cat", "/proc/cpuinfo")
+		cmd = exec.CommandContext(ctx, "cat", "/proc/cpuinfo")
 	case "windows":
 		// 使用PowerShell替代已弃用的wmic命令
-		cmd = exec.Command("powershell", "-Command", "Get-CimInstance -ClassName Win32_Processor | Select-Object -ExpandProperty ProcessorId")
+		cmd = exec.CommandContext(ctx, "powershell", "-Command", "Get-CimInstance -ClassName Win32_Processor | Select-Object -ExpandProperty ProcessorId")
 	default:
 		return "", fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
 
 	output, err := cmd.Output()
 	if err != nil {
-		return "", fmt.Errorf("failed to execute command: %v", err)
+		return "", commandError(ctx, err)
 	}
 
 	cpuid := parseCPUID(string(output), runtime.GOOS)
@@ -165,6 +203,16 @@ func parseCPUID(output, os string) string {
 
 // GetAllInfo 获取所有机器信息
 func GetAllInfo() (*MachineInfo, error) {
+	return GetAllInfoContext(context.Background())
+}
+
+// GetAllInfoContext 获取所有机器信息
+// 单个组件获取失败时该组件为空；ctx 超时返回 ErrTimeout，被取消时返回 ctx.Err()
+func GetAllInfoContext(ctx context.Context) (*MachineInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+
 	info := &MachineInfo{}
 
 	mac, err := GetMACAddress()
@@ -172,15 +220,35 @@ func GetAllInfo() (*MachineInfo, error) {
 		info.MAC = mac
 	}
 
-	uuid, err := GetSystemUUID()
+	uuid, err := GetSystemUUIDContext(ctx)
 	if err == nil {
 		info.UUID = uuid
 	}
 
-	cpuid, err := GetCPUID()
+	cpuid, err := GetCPUIDContext(ctx)
 	if err == nil {
 		info.CPUID = cpuid
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+
 	return info, nil
 }
+
+// commandError 返回外部命令失败的错误，命令因 ctx 取消或超时被终止时返回对应的错误
+func commandError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return contextError(ctxErr)
+	}
+	return fmt.Errorf("failed to execute command: %v", err)
+}
+
+// contextError 将超时转换为 ErrTimeout，同时保留 context 的错误
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
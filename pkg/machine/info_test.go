@@ -1,7 +1,10 @@
 package machine
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestGetMACAddress(t *testing.T) {
@@ -59,3 +62,20 @@ func TestGetAllInfo(t *testing.T) {
 
 	t.Logf("Machine Info: MAC=%s, UUID=%s, CPUID=%s", info.MAC, info.UUID, info.CPUID)
 }
+
+func TestGetAllInfoContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	_, err := GetAllInfoContext(ctx)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetAllInfoContext() error = %v, want %v", err, ErrTimeout)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := GetCPUIDContext(ctx); !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("GetCPUIDContext() error = %v, want %v", err, context.Canceled)
+	}
+}
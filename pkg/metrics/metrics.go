@@ -0,0 +1,259 @@
+// Package metrics 以 Prometheus 文本格式导出许可证状态指标，不依赖 Prometheus 客户端库。
+//
+// Collector 通过 license.WithObserver 接收每次验证的结果：
+//
+//	collector := metrics.NewCollector(metrics.WithFeatures("reports"))
+//	verifier, _ := license.NewVerifierFromFiles("keys/public.pem", "keys/aes.key",
+//		license.WithObserver(collector.Observe))
+//	watcher := license.NewWatcher(verifier, "license.lic")
+//	go watcher.Run(ctx)
+//	http.Handle("/metrics", collector)
+//
+// 导出的指标：
+//
+//	license_valid                                当前许可证是否有效（1 或 0）
+//	license_expires_in_seconds                   距许可证（或签到租约、产品授权）过期的秒数，已过期时为负数
+//	license_verification_total{outcome}          按结果统计的验证次数，outcome 为 valid 或第一个失败的检查项
+//	license_verification_cache_hits_total{outcome}  按结果统计的 license.CachingVerifier 缓存命中次数
+//	license_feature_enabled{feature}             功能是否启用
+//	license_last_verification_timestamp_seconds  最近一次验证的时间
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// OutcomeValid 验证通过时 license_verification_total 的 outcome 标签
+const OutcomeValid = "valid"
+
+// ResultSource 提供当前的验证结果，license.Watcher 实现了该接口
+// 热加载许可证时使用 license.ReloadableVerifier.NewWatcher 创建的监视器
+type ResultSource interface {
+	Result() *license.VerificationResult
+}
+
+// Collector 许可证指标收集器，实现 http.Handler
+type Collector struct {
+	source   ResultSource
+	features []string
+	clock    license.Clock
+
+	mu        sync.Mutex
+	result    *license.VerificationResult
+	outcomes  map[string]uint64
+	cacheHits map[string]uint64
+}
+
+// Option 收集器选项
+type Option func(*Collector)
+
+// WithSource 导出时从 source 读取当前的验证结果，而不是使用最近一次 Observe 的结果
+func WithSource(source ResultSource) Option {
+	return func(c *Collector) {
+		c.source = source
+	}
+}
+
+// WithFeatures 始终导出这些功能的 license_feature_enabled，许可证不包含时为 0
+func WithFeatures(features ...string) Option {
+	return func(c *Collector) {
+		c.features = append(c.features, features...)
+	}
+}
+
+// WithClock 使用指定的时钟计算剩余有效期
+func WithClock(clock license.Clock) Option {
+	return func(c *Collector) {
+		c.clock = clock
+	}
+}
+
+// NewCollector 创建指标收集器
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{
+		clock:     license.ClockFunc(time.Now),
+		outcomes:  make(map[string]uint64),
+		cacheHits: make(map[string]uint64),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Observe 记录一次验证结果，可作为 license.WithObserver 的参数
+// 缓存命中的结果只计入 license_verification_cache_hits_total，不替换当前的验证结果
+func (c *Collector) Observe(result *license.VerificationResult) {
+	if result == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if result.Cached {
+		c.cacheHits[outcome(result)]++
+		return
+	}
+	c.result = result
+	c.outcomes[outcome(result)]++
+}
+
+// outcome 返回验证结果的 outcome 标签
+func outcome(result *license.VerificationResult) string {
+	if result.Valid {
+		return OutcomeValid
+	}
+
+	var verr *license.VerificationError
+	if errors.As(result.Err, &verr) {
+		return verr.Check
+	}
+	return "invalid"
+}
+
+// ServeHTTP 以 Prometheus 文本格式输出指标
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	c.WriteTo(w)
+}
+
+// WriteTo 以 Prometheus 文本格式写出指标
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	result := c.result
+	outcomes := make(map[string]uint64, len(c.outcomes))
+	for k, v := range c.outcomes {
+		outcomes[k] = v
+	}
+	cacheHits := make(map[string]uint64, len(c.cacheHits))
+	for k, v := range c.cacheHits {
+		cacheHits[k] = v
+	}
+	c.mu.Unlock()
+
+	if c.source != nil {
+		result = c.source.Result()
+	}
+
+	var b strings.Builder
+
+	// 许可证有效性
+	var labels string
+	valid := 0
+	if result != nil {
+		if result.License != nil {
+			labels = formatLabels(
+				"license_id", result.License.ID,
+				"customer", result.License.CustomerName,
+				"product", result.License.ProductName)
+		}
+		if result.Valid {
+			valid = 1
+		}
+	}
+	writeHeader(&b, "license_valid", "gauge", "Whether the license is currently valid (1) or not (0).")
+	fmt.Fprintf(&b, "license_valid%s %d\n", labels, valid)
+
+	// 剩余有效期
+	if result != nil && result.License != nil {
+		deadline := result.License.ExpiresAt
+		if result.ExpiresIn > 0 {
+			deadline = result.VerifiedAt.Add(time.Duration(result.ExpiresIn) * time.Second)
+		}
+		writeHeader(&b, "license_expires_in_seconds", "gauge", "Seconds until the license, its check-in lease or product grant expires.")
+		fmt.Fprintf(&b, "license_expires_in_seconds%s %d\n", labels, int64(deadline.Sub(c.clock.Now()).Seconds()))
+	}
+
+	// 验证次数
+	writeHeader(&b, "license_verification_total", "counter", "Number of license verifications by outcome.")
+	if _, ok := outcomes[OutcomeValid]; !ok {
+		outcomes[OutcomeValid] = 0
+	}
+	for _, name := range sortedKeys(outcomes) {
+		fmt.Fprintf(&b, "license_verification_total%s %d\n", formatLabels("outcome", name), outcomes[name])
+	}
+	if len(cacheHits) > 0 {
+		writeHeader(&b, "license_verification_cache_hits_total", "counter", "Number of license verifications answered from the cache by outcome.")
+		for _, name := range sortedKeys(cacheHits) {
+			fmt.Fprintf(&b, "license_verification_cache_hits_total%s %d\n", formatLabels("outcome", name), cacheHits[name])
+		}
+	}
+
+	// 功能
+	features := make(map[string]bool)
+	for _, feature := range c.features {
+		features[feature] = false
+	}
+	// 许可证包含的功能始终导出，许可证无效时为 0，避免时间序列消失
+	if result != nil {
+		var granted []string
+		switch {
+		case result.Grant != nil:
+			granted = result.Grant.Features
+		case result.License != nil:
+			granted = result.License.Features
+		}
+		for _, feature := range granted {
+			features[feature] = result.Valid
+		}
+	}
+	if len(features) > 0 {
+		writeHeader(&b, "license_feature_enabled", "gauge", "Whether a licensed feature is enabled (1) or not (0).")
+		for _, feature := range sortedKeys(features) {
+			enabled := 0
+			if features[feature] {
+				enabled = 1
+			}
+			fmt.Fprintf(&b, "license_feature_enabled%s %d\n", formatLabels("feature", feature), enabled)
+		}
+	}
+
+	// 最近一次验证时间
+	if result != nil {
+		writeHeader(&b, "license_last_verification_timestamp_seconds", "gauge", "Unix time of the last license verification.")
+		fmt.Fprintf(&b, "license_last_verification_timestamp_seconds %d\n", result.VerifiedAt.Unix())
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeHeader 写出指标的 HELP 和 TYPE 行
+func writeHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// formatLabels 格式化标签，参数为名称和值交替的列表
+func formatLabels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], labelEscaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// labelEscaper 转义标签值中的反斜杠、双引号和换行
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sortedKeys 返回排序后的键，使输出稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+	"github.com/cuilan/license-key-verify/pkg/metrics"
+)
+
+// scrape 请求指标并返回响应体
+func scrape(t *testing.T, collector *metrics.Collector) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, metrics.ContentType)
+	}
+	return rec.Body.String()
+}
+
+// assertContains 检查输出包含指定的指标行
+func assertContains(t *testing.T, body string, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics missing %q:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorObserve(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+
+	now := time.Now().Add(time.Minute)
+	clock := licensetest.NewClock(now)
+	collector := metrics.NewCollector(metrics.WithFeatures("export"), metrics.WithClock(clock))
+
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(),
+		license.WithClock(clock),
+		license.WithMachineInfoProvider(licensetest.NewMachineInfo("", "", "")),
+		license.WithObserver(collector.Observe))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	// 尚未验证
+	body := scrape(t, collector)
+	assertContains(t, body, "license_valid 0", `license_verification_total{outcome="valid"} 0`)
+
+	lic, err := generator.Generate(&license.GenerateOptions{
+		CustomerName: `ACME "Labs"`,
+		Features:     []string{"reports"},
+		Duration:     24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	data, err := generator.Encode(lic)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := verifier.Verify(data); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+
+	labels := `{license_id="` + lic.ID + `",customer="ACME \"Labs\"",product="` + lic.ProductName + `"}`
+	expiresIn := int64(lic.ExpiresAt.Sub(now).Seconds())
+
+	body = scrape(t, collector)
+	assertContains(t, body,
+		"# TYPE license_valid gauge",
+		"license_valid"+labels+" 1",
+		"license_expires_in_seconds"+labels+" "+strconv.FormatInt(expiresIn, 10),
+		"# TYPE license_verification_total counter",
+		`license_verification_total{outcome="valid"} 2`,
+		`license_feature_enabled{feature="export"} 0`,
+		`license_feature_enabled{feature="reports"} 1`)
+
+	// 过期后
+	clock.Set(lic.ExpiresAt.Add(time.Hour))
+	if _, err := verifier.Verify(data); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	body = scrape(t, collector)
+	assertContains(t, body,
+		"license_valid"+labels+" 0",
+		"license_expires_in_seconds"+labels+" -3600",
+		`license_verification_total{outcome="valid"} 2`,
+		`license_verification_total{outcome="expiry"} 1`,
+		`license_feature_enabled{feature="reports"} 0`)
+
+	// 读取失败也会统计
+	if _, err := verifier.VerifyFile("missing.lic"); err != nil {
+		t.Fatalf("VerifyFile() error = %v", err)
+	}
+	assertContains(t, scrape(t, collector), `license_verification_total{outcome="format"} 1`, "license_valid 0")
+}
+
+func TestCollectorCacheHits(t *testing.T) {
+	generator, err := license.NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator() error = %v", err)
+	}
+	publicKeyPEM, _ := generator.GetPublicKeyPEM()
+
+	clock := licensetest.NewClock(time.Now().Add(time.Minute))
+	collector := metrics.NewCollector(metrics.WithClock(clock))
+	verifier, err := license.NewVerifier(publicKeyPEM, generator.GetAESKey(),
+		license.WithClock(clock),
+		license.WithMachineInfoProvider(licensetest.NewMachineInfo("", "", "")),
+		license.WithObserver(collector.Observe))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	lic, _ := generator.Generate(&license.GenerateOptions{Duration: 24 * time.Hour})
+	data, _ := generator.Encode(lic)
+
+	cache := license.NewCachingVerifier(verifier, time.Hour)
+	for i := 0; i < 3; i++ {
+		cache.Verify(data)
+	}
+
+	// 缓存命中单独统计，不计入实际验证次数
+	assertContains(t, scrape(t, collector),
+		`license_verification_total{outcome="valid"} 1`,
+		"# TYPE license_verification_cache_hits_total counter",
+		`license_verification_cache_hits_total{outcome="valid"} 2`)
+}
+
+func TestCollectorSource(t *testing.T) {
+	result := &license.VerificationResult{
+		Valid:      true,
+		License:    &license.License{ID: "test", Features: []string{"reports"}, ExpiresAt: time.Now().Add(time.Hour)},
+		VerifiedAt: time.Now(),
+	}
+	collector := metrics.NewCollector(metrics.WithSource(resultFunc(func() *license.VerificationResult { return result })))
+
+	assertContains(t, scrape(t, collector),
+		`license_valid{license_id="test",customer="",product=""} 1`,
+		`license_feature_enabled{feature="reports"} 1`)
+}
+
+type resultFunc func() *license.VerificationResult
+
+func (f resultFunc) Result() *license.VerificationResult { return f() }
@@ -0,0 +1,121 @@
+// Package middleware 提供检查许可证的 net/http 中间件。
+//
+// 中间件不在请求路径上验证许可证，而是读取由 license.Watcher 等组件在后台维护的验证结果：
+//
+//	watcher := license.NewWatcher(verifier, "license.lic")
+//	go watcher.Run(ctx)
+//	mux.Handle("/reports", middleware.Require(watcher, "reports")(reportsHandler))
+//
+// 许可证无效时返回 402，缺少所需功能时返回 403，响应体为 RFC 7807 格式的 JSON。
+// 处理器可以通过 middleware.ResultFromContext 获取当前的验证结果。
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// ResultSource 提供当前的验证结果，license.Watcher 实现了该接口
+// 热加载许可证时使用 license.ReloadableVerifier.NewWatcher 创建的监视器
+// 尚未验证时返回 nil
+type ResultSource interface {
+	Result() *license.VerificationResult
+}
+
+// ResultFunc 将函数适配为 ResultSource
+type ResultFunc func() *license.VerificationResult
+
+// Result 返回验证结果
+func (f ResultFunc) Result() *license.VerificationResult {
+	return f()
+}
+
+// Problem RFC 7807 格式的错误响应
+type Problem struct {
+	Type    string   `json:"type"`              // 错误类型
+	Title   string   `json:"title"`             // 简短说明
+	Status  int      `json:"status"`            // HTTP状态码
+	Detail  string   `json:"detail,omitempty"`  // 详细说明
+	Check   string   `json:"check,omitempty"`   // 失败的检查项
+	Missing []string `json:"missing,omitempty"` // 缺少的功能
+}
+
+// 错误类型
+const (
+	ProblemLicenseInvalid = "urn:license-key-verify:license-invalid"
+	ProblemFeatureMissing = "urn:license-key-verify:feature-missing"
+)
+
+// contextKey 请求上下文中验证结果的键
+type contextKey struct{}
+
+// ResultFromContext 返回中间件放入请求上下文的验证结果
+func ResultFromContext(ctx context.Context) (*license.VerificationResult, bool) {
+	result, ok := ctx.Value(contextKey{}).(*license.VerificationResult)
+	return result, ok && result != nil
+}
+
+// Require 返回中间件：许可证有效且包含所有 features 时才调用下一个处理器
+func Require(source ResultSource, features ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result := source.Result()
+			if result == nil {
+				writeProblem(w, &Problem{
+					Type:   ProblemLicenseInvalid,
+					Title:  "License not verified",
+					Status: http.StatusPaymentRequired,
+					Detail: "the license has not been verified yet",
+				})
+				return
+			}
+
+			if !result.Valid {
+				problem := &Problem{
+					Type:   ProblemLicenseInvalid,
+					Title:  "License invalid",
+					Status: http.StatusPaymentRequired,
+					Detail: result.Error,
+				}
+				var verr *license.VerificationError
+				if errors.As(result.Err, &verr) {
+					problem.Check = verr.Check
+				}
+				writeProblem(w, problem)
+				return
+			}
+
+			var missing []string
+			for _, feature := range features {
+				if !result.HasFeature(feature) {
+					missing = append(missing, feature)
+				}
+			}
+			if len(missing) > 0 {
+				writeProblem(w, &Problem{
+					Type:    ProblemFeatureMissing,
+					Title:   "Feature not licensed",
+					Status:  http.StatusForbidden,
+					Detail:  fmt.Sprintf("the license does not include: %s", strings.Join(missing, ", ")),
+					Missing: missing,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, result)))
+		})
+	}
+}
+
+// writeProblem 输出错误响应
+func writeProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
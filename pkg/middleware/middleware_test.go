@@ -0,0 +1,74 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/middleware"
+)
+
+func TestRequire(t *testing.T) {
+	valid := &license.VerificationResult{
+		Valid:   true,
+		License: &license.License{ID: "test", Features: []string{"reports"}},
+	}
+	expired := &license.VerificationResult{
+		Error: "license has expired",
+		Err:   &license.VerificationError{Check: license.CheckExpiry, Err: license.ErrExpired},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := middleware.ResultFromContext(r.Context())
+		if !ok {
+			t.Error("ResultFromContext() returned no result")
+			return
+		}
+		w.Write([]byte(result.License.ID))
+	})
+
+	tests := []struct {
+		name       string
+		result     *license.VerificationResult
+		features   []string
+		wantStatus int
+		wantType   string
+		wantCheck  string
+	}{
+		{name: "valid", result: valid, features: []string{"reports"}, wantStatus: http.StatusOK},
+		{name: "missing feature", result: valid, features: []string{"reports", "export"}, wantStatus: http.StatusForbidden, wantType: middleware.ProblemFeatureMissing},
+		{name: "expired", result: expired, wantStatus: http.StatusPaymentRequired, wantType: middleware.ProblemLicenseInvalid, wantCheck: license.CheckExpiry},
+		{name: "not verified", result: nil, wantStatus: http.StatusPaymentRequired, wantType: middleware.ProblemLicenseInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := middleware.ResultFunc(func() *license.VerificationResult { return tt.result })
+			rec := httptest.NewRecorder()
+			middleware.Require(source, tt.features...)(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if rec.Body.String() != "test" {
+					t.Errorf("body = %q, want handler output", rec.Body.String())
+				}
+				return
+			}
+
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", ct)
+			}
+			var problem middleware.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid problem body: %v", err)
+			}
+			if problem.Type != tt.wantType || problem.Status != tt.wantStatus || problem.Check != tt.wantCheck {
+				t.Errorf("problem = %+v, want type %s, check %q", problem, tt.wantType, tt.wantCheck)
+			}
+		})
+	}
+}
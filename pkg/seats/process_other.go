@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package seats
+
+// processAlive 无法检查进程时总是认为进程存在，只依靠心跳超时回收租约
+func processAlive(pid int) bool {
+	return pid > 0
+}
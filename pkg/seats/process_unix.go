@@ -0,0 +1,17 @@
+//go:build unix
+
+package seats
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive 判断进程是否存在，发送信号 0 只检查权限不实际发送信号
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package seats
+
+import (
+	"os"
+)
+
+// processAlive 判断进程是否存在，Windows 上 FindProcess 在进程不存在时返回错误
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
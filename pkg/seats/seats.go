@@ -0,0 +1,338 @@
+// Package seats 在单台机器上按 License.MaxUsers 限制同时运行的进程数，无需网络服务器。
+//
+// 每个持有席位的进程在席位目录中写入一个租约文件（进程号 + 心跳时间），
+// 统计席位时忽略并清理进程已退出或心跳超时的租约文件。
+// 对目录的修改通过锁目录（mkdir 是原子操作）串行化，锁本身超时后会被强制释放，避免进程崩溃后死锁。
+//
+//	manager, _ := seats.NewManager("/var/lib/myapp/seats", result.License)
+//	seat, err := manager.AcquireSeat()
+//	if errors.Is(err, seats.ErrNoSeatsAvailable) { ... }
+//	defer manager.ReleaseSeat(seat)
+//	go seat.KeepAlive(ctx)
+package seats
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cuilan/license-key-verify/internal/filelock"
+	"github.com/cuilan/license-key-verify/pkg/license"
+)
+
+// 默认参数
+const (
+	DefaultHeartbeatInterval = 30 * time.Second // 默认心跳间隔
+	DefaultStaleAfter        = 2 * time.Minute  // 默认的租约超时时间，超过该时间未心跳的租约视为失效
+	DefaultLockTimeout       = 10 * time.Second // 默认的获取锁超时时间
+)
+
+// lockStaleAfter 锁目录存在超过该时间视为持锁进程已崩溃
+const lockStaleAfter = 30 * time.Second
+
+// leaseSuffix 租约文件的扩展名
+const leaseSuffix = ".seat"
+
+// 席位错误类型
+var (
+	ErrNoSeatsAvailable = errors.New("no seats available")
+	ErrSeatReleased     = errors.New("seat has been released or reclaimed")
+	ErrLockTimeout      = errors.New("timed out waiting for seat lock")
+)
+
+// Lease 租约文件内容
+type Lease struct {
+	ID        string    `json:"id"`         // 租约ID
+	PID       int       `json:"pid"`        // 持有席位的进程号
+	Hostname  string    `json:"hostname"`   // 主机名
+	User      string    `json:"user"`       // 用户名
+	Acquired  time.Time `json:"acquired"`   // 获取时间
+	Heartbeat time.Time `json:"heartbeat"`  // 最后心跳时间
+	LicenseID string    `json:"license_id"` // 许可证ID
+}
+
+// Manager 席位管理器
+type Manager struct {
+	dir               string
+	licenseID         string
+	maxUsers          int
+	heartbeatInterval time.Duration
+	staleAfter        time.Duration
+	lockTimeout       time.Duration
+	now               func() time.Time
+	alive             func(pid int) bool
+}
+
+// Option 席位管理器选项
+type Option func(*Manager)
+
+// WithHeartbeat 设置心跳间隔和租约超时时间，staleAfter 应大于 interval 的两倍
+func WithHeartbeat(interval, staleAfter time.Duration) Option {
+	return func(m *Manager) {
+		m.heartbeatInterval = interval
+		m.staleAfter = staleAfter
+	}
+}
+
+// WithLockTimeout 设置获取锁的超时时间
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithClock 设置判断心跳超时使用的时钟
+func WithClock(clock license.Clock) Option {
+	return func(m *Manager) {
+		m.now = clock.Now
+	}
+}
+
+// NewManager 为许可证创建席位管理器，dir 为所有进程共享的席位目录
+// 许可证的 MaxUsers 为 0 时不限制席位数
+func NewManager(dir string, lic *license.License, opts ...Option) (*Manager, error) {
+	if lic == nil {
+		return nil, fmt.Errorf("license cannot be nil")
+	}
+
+	m := &Manager{
+		dir:               filepath.Join(dir, lic.ID),
+		licenseID:         lic.ID,
+		maxUsers:          lic.MaxUsers,
+		heartbeatInterval: DefaultHeartbeatInterval,
+		staleAfter:        DefaultStaleAfter,
+		lockTimeout:       DefaultLockTimeout,
+		now:               time.Now,
+		alive:             processAlive,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.heartbeatInterval <= 0 || m.staleAfter <= m.heartbeatInterval {
+		return nil, fmt.Errorf("stale timeout must be longer than the heartbeat interval")
+	}
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create seat directory: %v", err)
+	}
+
+	return m, nil
+}
+
+// Seat 当前进程持有的席位
+type Seat struct {
+	manager *Manager
+	path    string
+	lease   Lease
+}
+
+// AcquireSeat 获取一个席位，席位已满时返回 ErrNoSeatsAvailable
+func (m *Manager) AcquireSeat() (*Seat, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	leases, err := m.activeLeases()
+	if err != nil {
+		return nil, err
+	}
+
+	if m.maxUsers > 0 && len(leases) >= m.maxUsers {
+		return nil, fmt.Errorf("%w: all %d seats are in use", ErrNoSeatsAvailable, m.maxUsers)
+	}
+
+	id, err := newLeaseID()
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	now := m.now()
+	seat := &Seat{
+		manager: m,
+		path:    filepath.Join(m.dir, id+leaseSuffix),
+		lease: Lease{
+			ID:        id,
+			PID:       os.Getpid(),
+			Hostname:  hostname,
+			User:      currentUser(),
+			Acquired:  now,
+			Heartbeat: now,
+			LicenseID: m.licenseID,
+		},
+	}
+
+	if err := writeLease(seat.path, &seat.lease); err != nil {
+		return nil, err
+	}
+	return seat, nil
+}
+
+// ReleaseSeat 释放席位，席位已被回收时不返回错误
+func (m *Manager) ReleaseSeat(seat *Seat) error {
+	if seat == nil {
+		return nil
+	}
+	if err := os.Remove(seat.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release seat: %v", err)
+	}
+	return nil
+}
+
+// Leases 返回当前有效的租约，同时清理失效的租约文件
+func (m *Manager) Leases() ([]Lease, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return m.activeLeases()
+}
+
+// Lease 返回席位的租约信息
+func (s *Seat) Lease() Lease {
+	return s.lease
+}
+
+// Heartbeat 更新心跳时间
+// 租约文件已被删除（例如进程挂起过久被其他进程回收）时返回 ErrSeatReleased，此时应重新获取席位
+// 与回收在同一把锁下进行，避免重新写入刚被其他进程回收的租约文件
+func (s *Seat) Heartbeat() error {
+	unlock, err := s.manager.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return ErrSeatReleased
+	}
+
+	s.lease.Heartbeat = s.manager.now()
+	return writeLease(s.path, &s.lease)
+}
+
+// KeepAlive 按心跳间隔定期更新心跳，直到 ctx 取消或心跳失败
+func (s *Seat) KeepAlive(ctx context.Context) error {
+	ticker := time.NewTicker(s.manager.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.Heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// activeLeases 读取所有租约文件，删除失效的租约，调用方需持有锁
+func (m *Manager) activeLeases() ([]Lease, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seat directory: %v", err)
+	}
+
+	now := m.now()
+	var leases []Lease
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), leaseSuffix) {
+			continue
+		}
+
+		path := filepath.Join(m.dir, entry.Name())
+		lease, err := readLease(path)
+		if err != nil || m.stale(lease, now) {
+			os.Remove(path)
+			continue
+		}
+		leases = append(leases, *lease)
+	}
+	return leases, nil
+}
+
+// stale 判断租约是否失效：同一主机上的进程已退出，或心跳超时
+func (m *Manager) stale(lease *Lease, now time.Time) bool {
+	if now.Sub(lease.Heartbeat) > m.staleAfter {
+		return true
+	}
+	if hostname, _ := os.Hostname(); lease.Hostname == hostname && !m.alive(lease.PID) {
+		return true
+	}
+	return false
+}
+
+// lock 获取席位目录的锁，返回释放锁的函数
+func (m *Manager) lock() (func(), error) {
+	unlock, err := filelock.Lock(filepath.Join(m.dir, ".lock"), m.lockTimeout, lockStaleAfter)
+	if errors.Is(err, filelock.ErrTimeout) {
+		return nil, ErrLockTimeout
+	}
+	return unlock, err
+}
+
+// readLease 读取租约文件
+func readLease(path string) (*Lease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lease Lease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// writeLease 原子地写入租约文件
+func writeLease(path string, lease *Lease) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return fmt.Errorf("failed to marshal seat lease: %v", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write seat lease: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write seat lease: %v", err)
+	}
+	return nil
+}
+
+// newLeaseID 生成随机租约ID
+func newLeaseID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lease ID: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// currentUser 返回当前用户名
+func currentUser() string {
+	for _, key := range []string{"USER", "USERNAME"} {
+		if user := os.Getenv(key); user != "" {
+			return user
+		}
+	}
+	return ""
+}
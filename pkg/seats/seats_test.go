@@ -0,0 +1,88 @@
+package seats_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cuilan/license-key-verify/pkg/license"
+	"github.com/cuilan/license-key-verify/pkg/license/licensetest"
+	"github.com/cuilan/license-key-verify/pkg/seats"
+)
+
+func TestAcquireSeat(t *testing.T) {
+	dir := t.TempDir()
+	clock := licensetest.NewClock(time.Now())
+	lic := &license.License{ID: "test-license", MaxUsers: 2}
+
+	manager, err := seats.NewManager(dir, lic, seats.WithClock(clock), seats.WithHeartbeat(time.Second, time.Minute))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	first, err := manager.AcquireSeat()
+	if err != nil {
+		t.Fatalf("AcquireSeat() error = %v", err)
+	}
+	second, err := manager.AcquireSeat()
+	if err != nil {
+		t.Fatalf("AcquireSeat() error = %v", err)
+	}
+	if _, err := manager.AcquireSeat(); !errors.Is(err, seats.ErrNoSeatsAvailable) {
+		t.Fatalf("AcquireSeat() error = %v, want %v", err, seats.ErrNoSeatsAvailable)
+	}
+
+	if err := manager.ReleaseSeat(first); err != nil {
+		t.Fatalf("ReleaseSeat() error = %v", err)
+	}
+	if _, err := manager.AcquireSeat(); err != nil {
+		t.Fatalf("AcquireSeat() after release error = %v", err)
+	}
+
+	// 心跳超时的租约被回收
+	clock.Advance(30 * time.Second)
+	if err := second.Heartbeat(); err != nil {
+		t.Fatalf("Heartbeat() error = %v", err)
+	}
+	clock.Advance(40 * time.Second)
+
+	leases, err := manager.Leases()
+	if err != nil {
+		t.Fatalf("Leases() error = %v", err)
+	}
+	if len(leases) != 1 || leases[0].ID != second.Lease().ID {
+		t.Errorf("Leases() = %+v, want only the seat with a recent heartbeat", leases)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, err := manager.AcquireSeat(); err != nil {
+		t.Fatalf("AcquireSeat() error = %v", err)
+	}
+	if err := second.Heartbeat(); !errors.Is(err, seats.ErrSeatReleased) {
+		t.Errorf("Heartbeat() on reclaimed seat error = %v, want %v", err, seats.ErrSeatReleased)
+	}
+}
+
+func TestDeadProcessLease(t *testing.T) {
+	dir := t.TempDir()
+	lic := &license.License{ID: "test-license", MaxUsers: 1}
+
+	manager, err := seats.NewManager(dir, lic)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	// 本机上已退出的进程留下的租约
+	hostname, _ := os.Hostname()
+	data, _ := json.Marshal(&seats.Lease{ID: "dead", PID: 1 << 30, Hostname: hostname, Heartbeat: time.Now()})
+	if err := os.WriteFile(filepath.Join(dir, lic.ID, "dead.seat"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.AcquireSeat(); err != nil {
+		t.Errorf("AcquireSeat() error = %v, want the dead process lease to be reclaimed", err)
+	}
+}
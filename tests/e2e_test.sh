@@ -50,8 +50,8 @@ echo "---- 4.2. Attempting to verify the original license with wrong keys (expec
 # We expect this command to fail, so we temporarily disable 'exit on error'
 set +e
 $LKVERIFY $LICENSE_FILE --public-key $KEYS_DIR_2/public.pem --aes-key $KEYS_DIR_2/aes.key
-# Check that the command failed as expected (exit code 1)
-if [ $? -ne 1 ]; then
+# Check that the command failed as expected (exit code 5: signature verification failed)
+if [ $? -ne 5 ]; then
     echo "ERROR: Verification with wrong keys succeeded, but it should have failed."
     exit 1
 fi